@@ -0,0 +1,479 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/reilandeubank/golox/pkg/cover"
+	"github.com/reilandeubank/golox/pkg/dap"
+	"github.com/reilandeubank/golox/pkg/format"
+	"github.com/reilandeubank/golox/pkg/interpreter"
+	"github.com/reilandeubank/golox/pkg/loxtest"
+	"github.com/reilandeubank/golox/pkg/lsp"
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+	"github.com/reilandeubank/golox/pkg/vet"
+)
+
+// commands are the subcommands selected by golox's first argument
+var commands = map[string]func(args []string){
+	"tokens": tokensCommand,
+	"parse":  parseCommand,
+	"exec":   execCommand,
+	"fmt":    fmtCommand,
+	"vet":    vetCommand,
+	"lsp":    lspCommand,
+	"debug":  debugCommand,
+	"dap":    dapCommand,
+	"cover":  coverCommand,
+	"test":   testCommand,
+	"bench":  benchCommand,
+}
+
+// newFlagSet returns a flag set for a subcommand that exits with 64 on bad usage
+func newFlagSet(name string, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: golox "+name+" "+usage)
+		flags.PrintDefaults()
+		os.Exit(64)
+	}
+	return flags
+}
+
+// readFileArg reads the single file named on a subcommand's command line
+func readFileArg(flags *flag.FlagSet) string {
+	if flags.NArg() != 1 {
+		flags.Usage()
+	}
+	bytes, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(66)
+	}
+	return string(bytes)
+}
+
+// parseSource scans and parses source, exiting with 65 if it has syntax errors
+func parseSource(source string) []parser.Stmt {
+	thisScanner := scanner.NewScanner(source)
+	thisParser := parser.NewParser(thisScanner.ScanTokens())
+	statements, err := thisParser.Parse()
+	if err != nil || len(thisScanner.Errors) > 0 || len(thisParser.Errors) > 0 {
+		os.Exit(65)
+	}
+	return statements
+}
+
+func tokensCommand(args []string) {
+	flags := newFlagSet("tokens", "[--json] file.lox")
+	asJSON := flags.Bool("json", false, "print the tokens as a JSON array")
+	flags.Parse(args)
+
+	thisScanner := scanner.NewScanner(readFileArg(flags))
+	tokens := thisScanner.ScanTokens()
+	if len(thisScanner.Errors) > 0 {
+		os.Exit(65)
+	}
+
+	if *asJSON {
+		bytes, err := json.MarshalIndent(tokens, "", "  ")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(70)
+		}
+		fmt.Println(string(bytes))
+		return
+	}
+	printTokenTable(tokens)
+}
+
+// printTokenTable prints tokens as aligned columns of type, lexeme, literal and line
+func printTokenTable(tokens []scanner.Token) {
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "TYPE\tLEXEME\tLITERAL\tLINE")
+	for _, token := range tokens {
+		literal := ""
+		if token.Literal != nil {
+			literal = fmt.Sprintf("%v", token.Literal)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%d\n", token.Type, token.Lexeme, literal, token.Line)
+	}
+	table.Flush()
+}
+
+func parseCommand(args []string) {
+	flags := newFlagSet("parse", "[--json] file.lox")
+	asJSON := flags.Bool("json", false, "print the syntax tree as JSON, which golox exec can run")
+	flags.Parse(args)
+
+	statements := parseSource(readFileArg(flags))
+
+	if *asJSON {
+		bytes, err := parser.EncodeJSON(statements)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(70)
+		}
+		fmt.Println(string(bytes))
+		return
+	}
+	for _, stmt := range statements {
+		fmt.Println(parser.ASTPrinter{}.PrintStmt(stmt))
+	}
+}
+
+func execCommand(args []string) {
+	flags := newFlagSet("exec", "file.json")
+	flags.Parse(args)
+
+	statements, err := parser.DecodeJSON([]byte(readFileArg(flags)))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(65)
+	}
+	if err := i.Interpret(statements); err != nil {
+		os.Exit(70)
+	}
+}
+
+func fmtCommand(args []string) {
+	flags := newFlagSet("fmt", "[-w] [-d] [file.lox|dir ...]")
+	write := flags.Bool("w", false, "write the result back to each file instead of printing it")
+	showDiff := flags.Bool("d", false, "print a diff of the changes instead of the formatted source")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(os.Stderr, "golox fmt: -w needs files to write to, not standard input")
+			os.Exit(64)
+		}
+		source, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(66)
+		}
+		formatted, err := format.Source(string(source))
+		if err != nil {
+			os.Exit(65)
+		}
+		if *showDiff {
+			fmt.Print(format.Diff("<stdin>", "<stdin>", string(source), formatted))
+		} else {
+			fmt.Print(formatted)
+		}
+		return
+	}
+
+	exitCode := 0
+	for _, path := range loxFiles(flags.Args(), ".lox") {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Println(err)
+			exitCode = 66
+			continue
+		}
+		formatted, err := format.Source(string(source))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			exitCode = 65
+			continue
+		}
+
+		switch {
+		case *showDiff:
+			fmt.Print(format.Diff(path+".orig", path, string(source), formatted))
+		case *write:
+			if formatted != string(source) {
+				err = os.WriteFile(path, []byte(formatted), 0644)
+				if err != nil {
+					fmt.Println(err)
+					exitCode = 74
+				}
+			}
+		default:
+			fmt.Print(formatted)
+		}
+	}
+	os.Exit(exitCode)
+}
+
+func vetCommand(args []string) {
+	flags := newFlagSet("vet", "file.lox|dir ...")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+	}
+
+	exitCode := 0
+	for _, path := range loxFiles(flags.Args(), ".lox") {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Println(err)
+			exitCode = 66
+			continue
+		}
+		findings, err := vet.Check(string(source))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			exitCode = 65
+			continue
+		}
+		for _, finding := range findings {
+			fmt.Printf("%s:%s\n", path, finding)
+			if exitCode == 0 {
+				exitCode = 1
+			}
+		}
+	}
+	os.Exit(exitCode)
+}
+
+// loxFiles expands directories in paths to the files under them whose names end in suffix
+func loxFiles(paths []string, suffix string) []string {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() && strings.HasSuffix(file, suffix) {
+				files = append(files, file)
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// lspCommand serves the Language Server Protocol over stdin and stdout
+func lspCommand(args []string) {
+	flags := newFlagSet("lsp", "")
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		flags.Usage()
+	}
+
+	if err := lsp.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// dapCommand serves the Debug Adapter Protocol over stdin and stdout
+func dapCommand(args []string) {
+	flags := newFlagSet("dap", "")
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		flags.Usage()
+	}
+
+	if err := dap.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// coverCommand summarizes coverage profiles written by --coverage, optionally rendering
+// them as annotated HTML
+func coverCommand(args []string) {
+	flags := newFlagSet("cover", "[-html out.html] file.cov...")
+	htmlFile := flags.String("html", "", "also write the source annotated with its coverage to this HTML file")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+	}
+
+	// Profiles for the same script in different files are added together
+	var readers []io.Reader
+	for _, path := range flags.Args() {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(66)
+		}
+		defer file.Close()
+		readers = append(readers, file)
+	}
+	profiles, err := cover.ReadProfiles(io.MultiReader(readers...))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(65)
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, profile := range profiles {
+		fmt.Fprintf(table, "%s\t%s\n", profile.File, profile.Summary())
+	}
+	table.Flush()
+
+	if *htmlFile != "" {
+		out, err := os.Create(*htmlFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(74)
+		}
+		defer out.Close()
+		err = cover.WriteHTML(out, profiles, func(path string) (string, error) {
+			bytes, err := os.ReadFile(path)
+			return string(bytes), err
+		})
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(74)
+		}
+	}
+}
+
+// testCommand runs the test functions in *_test.lox files, exiting with 1 if any fail
+func testCommand(args []string) {
+	flags := newFlagSet("test", "[-run regexp] [-v] [file or directory...]")
+	run := flags.String("run", "", "only run tests whose names match this regular expression")
+	verbose := flags.Bool("v", false, "list every test and show the output of passing tests")
+	flags.Parse(args)
+
+	var filter *regexp.Regexp
+	if *run != "" {
+		var err error
+		filter, err = regexp.Compile(*run)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(64)
+		}
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	failed := false
+	for _, path := range loxFiles(paths, loxtest.Suffix) {
+		bytes, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Println(err)
+			failed = true
+			continue
+		}
+
+		start := time.Now()
+		results, err := loxtest.Run(string(bytes), filter)
+		if err != nil {
+			fmt.Printf("FAIL\t%s [%v]\n", path, err)
+			failed = true
+			continue
+		}
+		if len(results) == 0 {
+			fmt.Printf("?   \t%s\t[no tests to run]\n", path)
+			continue
+		}
+
+		fileFailed := false
+		for _, result := range results {
+			status := "PASS"
+			if result.Failed {
+				status = "FAIL"
+				fileFailed = true
+			} else if !*verbose {
+				continue
+			}
+			fmt.Printf("--- %s: %s (%.3fs)\n", status, result.Name, result.Elapsed.Seconds())
+			if result.Failed {
+				fmt.Printf("    %s:%d: %s\n", path, result.Line, result.Message)
+			}
+			for _, line := range strings.SplitAfter(result.Output, "\n") {
+				if line != "" {
+					fmt.Print("        ", line)
+				}
+			}
+		}
+
+		status := "ok  "
+		if fileFailed {
+			status = "FAIL"
+			failed = true
+		}
+		fmt.Printf("%s\t%s\t%.3fs\n", status, path, time.Since(start).Seconds())
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// benchCommand runs .lox benchmark files several times each and reports how long they took
+func benchCommand(args []string) {
+	flags := newFlagSet("bench", "[-count n] [-benchfmt] [file or directory...]")
+	count := flags.Int("count", 5, "how many times to run each benchmark")
+	benchfmt := flags.Bool("benchfmt", false, "print a line per run in the Go benchmark format, for tools such as benchstat")
+	flags.Parse(args)
+	if *count < 1 {
+		flags.Usage()
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"bench"}
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	if !*benchfmt {
+		fmt.Fprintln(table, "RUNS\tMIN\tMEAN\tMAX\t\tFILE")
+	}
+	failed := false
+	for _, path := range loxFiles(paths, ".lox") {
+		bytes, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+			continue
+		}
+		statements, ok := parseProgram(string(bytes))
+		if !ok {
+			failed = true
+			continue
+		}
+
+		var times []time.Duration
+		for run := 0; run < *count; run++ {
+			// The program's output would swamp the timings
+			i := interpreter.NewInterpreter(interpreter.WithStdout(io.Discard))
+			start := time.Now()
+			if err := i.Interpret(statements); err != nil {
+				failed = true
+				break
+			}
+			times = append(times, time.Since(start))
+		}
+		if len(times) < *count {
+			continue
+		}
+
+		if *benchfmt {
+			name := strings.TrimSuffix(filepath.Base(path), ".lox")
+			for _, t := range times {
+				fmt.Printf("BenchmarkLox/%s\t1\t%d ns/op\n", name, t.Nanoseconds())
+			}
+			continue
+		}
+		fastest, slowest, total := times[0], times[0], time.Duration(0)
+		for _, t := range times {
+			fastest, slowest, total = min(fastest, t), max(slowest, t), total+t
+		}
+		mean := total / time.Duration(len(times))
+		fmt.Fprintf(table, "%d\t%v\t%v\t%v\t\t%s\n", len(times),
+			fastest.Round(time.Microsecond), mean.Round(time.Microsecond), slowest.Round(time.Microsecond), path)
+	}
+	table.Flush()
+	if failed {
+		os.Exit(1)
+	}
+}
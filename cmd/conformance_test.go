@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"text/tabwriter"
+	"time"
+)
+
+// The conformance tests run every .lox file under a directory and check what golox does
+// against comments in the file, in the same format as the Crafting Interpreters test
+// suite at https://github.com/munificent/craftinginterpreters/tree/master/test:
+//
+//	print 1; // expect: 1
+//	print x; // expect runtime error: Undefined variable 'x'.
+//	var = 1; // Error at '=': Expect variable name.
+//	// [line 3] Error at end: Expect '}' after block.
+//
+// Each subdirectory is a chapter, so to see how far golox gets through the official suite:
+//
+//	go test ./cmd -run Conformance -v -suite path/to/craftinginterpreters/test
+
+var suite = flag.String("suite", filepath.Join("..", "test"), "directory of .lox conformance tests")
+
+// runMain is set in the environment when the test binary is started to act as golox
+const runMain = "GOLOX_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMain) != "" {
+		os.Args[0] = "golox"
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+var (
+	expectedOutput       = regexp.MustCompile(`// expect: ?(.*)`)
+	expectedError        = regexp.MustCompile(`// (Error.*)`)
+	expectedErrorLine    = regexp.MustCompile(`// \[((java|c) )?line (\d+)\] (Error.*)`)
+	expectedRuntimeError = regexp.MustCompile(`// expect runtime error: (.+)`)
+	nonTest              = regexp.MustCompile(`// nontest`)
+
+	// golox reports syntax errors as "[line N] Parse Error at 'x': message" and runtime
+	// errors as "[line N] Runtime Error: message"
+	syntaxError  = regexp.MustCompile(`^\[line (\d+)\] Parse (Error.*)$`)
+	runtimeError = regexp.MustCompile(`^\[line (\d+)\] Runtime Error: (.*)$`)
+)
+
+// expectations is what a test file says should happen when it runs
+type expectations struct {
+	output        []string
+	compileErrors []string // as "[line N] Error..."
+	runtimeError  string
+	runtimeLine   int
+	exitCode      int
+	skip          bool
+}
+
+func parseExpectations(source string) expectations {
+	var e expectations
+	for j, line := range strings.Split(source, "\n") {
+		number := j + 1
+		if nonTest.MatchString(line) {
+			e.skip = true
+			return e
+		}
+		if m := expectedOutput.FindStringSubmatch(line); m != nil {
+			e.output = append(e.output, m[1])
+			continue
+		}
+		if m := expectedErrorLine.FindStringSubmatch(line); m != nil {
+			// Errors specific to the C implementation don't apply to a tree-walker
+			if m[2] != "c" {
+				e.compileErrors = append(e.compileErrors, fmt.Sprintf("[line %s] %s", m[3], m[4]))
+				e.exitCode = 65
+			}
+			continue
+		}
+		if m := expectedError.FindStringSubmatch(line); m != nil {
+			e.compileErrors = append(e.compileErrors, fmt.Sprintf("[line %d] %s", number, m[1]))
+			e.exitCode = 65
+			continue
+		}
+		if m := expectedRuntimeError.FindStringSubmatch(line); m != nil {
+			e.runtimeError = m[1]
+			e.runtimeLine = number
+			e.exitCode = 70
+		}
+	}
+	return e
+}
+
+// check compares a run of golox against the expectations, returning every mismatch
+func (e expectations) check(stdout, stderr string, exitCode int) []string {
+	var failures []string
+	fail := func(format string, args ...interface{}) {
+		failures = append(failures, fmt.Sprintf(format, args...))
+	}
+
+	errorLines := lines(stderr)
+	if e.runtimeError != "" {
+		if len(errorLines) == 0 {
+			fail("expected runtime error %q and got none", e.runtimeError)
+		} else if m := runtimeError.FindStringSubmatch(errorLines[0]); m == nil {
+			fail("expected runtime error %q but got %q", e.runtimeError, errorLines[0])
+		} else {
+			if m[2] != e.runtimeError {
+				fail("expected runtime error %q but got %q", e.runtimeError, m[2])
+			}
+			if line, _ := strconv.Atoi(m[1]); line != e.runtimeLine {
+				fail("expected runtime error on line %d but was on line %s", e.runtimeLine, m[1])
+			}
+			errorLines = errorLines[1:]
+		}
+	} else {
+		found := make(map[string]bool)
+		var unexpected []string
+		for _, line := range errorLines {
+			if m := syntaxError.FindStringSubmatch(line); m != nil {
+				found[fmt.Sprintf("[line %s] %s", m[1], m[2])] = true
+			} else {
+				unexpected = append(unexpected, line)
+			}
+		}
+		for _, expected := range e.compileErrors {
+			if !found[expected] {
+				fail("missing expected error: %s", expected)
+			}
+			delete(found, expected)
+		}
+		for err := range found {
+			fail("unexpected error: %s", err)
+		}
+		errorLines = unexpected
+	}
+	for _, line := range errorLines {
+		fail("unexpected output on stderr: %s", line)
+	}
+
+	if exitCode != e.exitCode {
+		fail("expected exit code %d but got %d", e.exitCode, exitCode)
+	}
+
+	output := lines(stdout)
+	for j, line := range output {
+		if j >= len(e.output) {
+			fail("got output %q when none was expected", line)
+		} else if line != e.output[j] {
+			fail("expected output %q on line %d but got %q", e.output[j], j+1, line)
+		}
+	}
+	for _, line := range e.output[min(len(output), len(e.output)):] {
+		fail("missing expected output %q", line)
+	}
+	return failures
+}
+
+func lines(s string) []string {
+	var result []string
+	in := bufio.NewScanner(strings.NewReader(s))
+	for in.Scan() {
+		result = append(result, in.Text())
+	}
+	return result
+}
+
+// runGolox runs the test binary as golox on path
+func runGolox(t *testing.T, path string) (stdout, stderr string, exitCode int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, os.Args[0], path)
+	cmd.Env = append(os.Environ(), runMain+"=1")
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("timed out")
+	}
+	return out.String(), errOut.String(), exitCode
+}
+
+func TestConformance(t *testing.T) {
+	chapters := make(map[string][]string)
+	err := filepath.WalkDir(*suite, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".lox" {
+			return err
+		}
+		chapter := filepath.Dir(filepath.ToSlash(mustRel(t, *suite, path)))
+		chapters[chapter] = append(chapters[chapter], path)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(chapters))
+	for chapter := range chapters {
+		names = append(names, chapter)
+	}
+	sort.Strings(names)
+
+	var report bytes.Buffer
+	table := tabwriter.NewWriter(&report, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "PASSED\tTOTAL\t\tCHAPTER")
+	for _, chapter := range names {
+		passed, total := 0, 0
+		t.Run(chapter, func(t *testing.T) {
+			for _, path := range chapters[chapter] {
+				source, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				e := parseExpectations(string(source))
+				if e.skip {
+					continue
+				}
+				total++
+				ok := t.Run(strings.TrimSuffix(filepath.Base(path), ".lox"), func(t *testing.T) {
+					stdout, stderr, exitCode := runGolox(t, path)
+					for _, failure := range e.check(stdout, stderr, exitCode) {
+						t.Error(failure)
+					}
+				})
+				if ok {
+					passed++
+				}
+			}
+		})
+		fmt.Fprintf(table, "%d\t%d\t\t%s\n", passed, total, chapter)
+	}
+	table.Flush()
+	t.Log("conformance by chapter:\n" + report.String())
+}
+
+func mustRel(t *testing.T, base, path string) string {
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rel
+}
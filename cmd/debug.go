@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/reilandeubank/golox/pkg/debug"
+	"github.com/reilandeubank/golox/pkg/interpreter"
+)
+
+const debugHelp = `break <line>   stop whenever execution reaches line (b); with no line, list breakpoints
+delete <line>  remove the breakpoint on line (d)
+step           run to the next line, stepping into calls (s)
+next           run to the next line, stepping over calls (n)
+finish         run until the current function returns (f)
+continue       run until the next breakpoint (c)
+print <expr>   evaluate expr where the program is paused (p)
+locals         list the variables in scope, excluding globals
+backtrace      list the active calls, innermost first (bt)
+quit           stop the program (q)
+An empty line repeats the last command`
+
+// debugger is the terminal front end for a debug.Controller
+type debugger struct {
+	path        string
+	lines       []string
+	executable  map[int]bool
+	input       *bufio.Reader
+	controller  *debug.Controller
+	lastCommand string
+}
+
+func debugCommand(args []string) {
+	flags := newFlagSet("debug", "file.lox")
+	flags.Parse(args)
+	source := readFileArg(flags)
+	statements := parseSource(source)
+
+	d := &debugger{
+		path:       flags.Arg(0),
+		lines:      strings.Split(source, "\n"),
+		executable: debug.Lines(statements),
+		input:      bufio.NewReader(os.Stdin), // shared with the program's input
+	}
+	d.controller = debug.NewController(true, d.pause)
+	debugged := interpreter.NewInterpreter(interpreter.WithStdin(d.input), interpreter.WithHook(d.controller))
+
+	err := debugged.Interpret(statements)
+	if errors.Is(err, interpreter.ErrHalted) {
+		return
+	}
+	fmt.Println("Program finished.")
+	if err != nil {
+		os.Exit(70)
+	}
+}
+
+// pause shows where the program stopped and reads commands until one resumes it
+func (d *debugger) pause(stop debug.Stop) (debug.Action, error) {
+	switch stop.Reason {
+	case debug.ReasonBreakpoint:
+		fmt.Printf("Breakpoint at %s:%d\n", d.path, stop.Line)
+	case debug.ReasonEntry:
+		fmt.Printf("Stopped at the start of %s. Type help for a list of commands.\n", d.path)
+	}
+	d.list(stop.Line)
+
+	for {
+		fmt.Print("(golox) ")
+		line, err := d.input.ReadString('\n')
+		if err == io.EOF && line == "" {
+			fmt.Println()
+			return debug.Continue, interpreter.ErrHalted
+		} else if err != nil && err != io.EOF {
+			return debug.Continue, err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			line = d.lastCommand
+		}
+		d.lastCommand = line
+		command, arg, _ := strings.Cut(line, " ")
+		arg = strings.TrimSpace(arg)
+
+		switch command {
+		case "":
+		case "help", "h":
+			fmt.Println(debugHelp)
+		case "break", "b":
+			d.setBreakpoint(arg, true)
+		case "delete", "d":
+			d.setBreakpoint(arg, false)
+		case "step", "s":
+			return debug.Step, nil
+		case "next", "n":
+			return debug.Next, nil
+		case "finish", "f":
+			return debug.Finish, nil
+		case "continue", "c":
+			return debug.Continue, nil
+		case "print", "p":
+			d.print(stop.Interpreter, arg)
+		case "locals":
+			d.locals(stop.Interpreter)
+		case "backtrace", "bt":
+			for j, frame := range stop.Interpreter.Frames() {
+				fmt.Printf("#%d  %s at line %d\n", j, frame.Name, frame.Line)
+			}
+		case "quit", "q":
+			return debug.Continue, interpreter.ErrHalted
+		default:
+			fmt.Printf("Unknown command '%s'. Type help for a list of commands.\n", command)
+		}
+	}
+}
+
+func (d *debugger) list(line int) {
+	if line >= 1 && line <= len(d.lines) {
+		fmt.Printf("%4d  %s\n", line, strings.TrimRight(d.lines[line-1], "\r"))
+	}
+}
+
+func (d *debugger) setBreakpoint(arg string, enabled bool) {
+	if arg == "" && enabled {
+		breakpoints := d.controller.Breakpoints()
+		if len(breakpoints) == 0 {
+			fmt.Println("No breakpoints.")
+		}
+		for _, line := range breakpoints {
+			fmt.Printf("Breakpoint at line %d\n", line)
+		}
+		return
+	}
+
+	line, err := strconv.Atoi(arg)
+	if err != nil {
+		fmt.Printf("Expected a line number but got '%s'.\n", arg)
+		return
+	}
+	if enabled && !d.executable[line] {
+		fmt.Printf("Line %d has no statement to stop at.\n", line)
+		return
+	}
+	d.controller.SetBreakpoint(line, enabled)
+	if enabled {
+		fmt.Printf("Breakpoint set at line %d\n", line)
+	} else {
+		fmt.Printf("Breakpoint at line %d deleted\n", line)
+	}
+}
+
+func (d *debugger) print(paused *interpreter.Interpreter, source string) {
+	thisScanner := quietScanner(source)
+	tokens := thisScanner.ScanTokens()
+	if len(thisScanner.Errors) > 0 {
+		fmt.Println(thisScanner.Errors[0].Message)
+		return
+	}
+	thisParser := quietParser(tokens)
+	expr, err := thisParser.ParseExpression()
+	if err != nil {
+		fmt.Println("Syntax error:", err)
+		return
+	}
+
+	value, err := paused.EvaluateIn(paused.Frames()[0], expr)
+	var runtimeErr *interpreter.RuntimeError
+	if errors.As(err, &runtimeErr) {
+		// The line in the error is relative to the expression, not the program
+		fmt.Println("Error:", runtimeErr.Message)
+		return
+	} else if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(interpreter.Stringify(value))
+}
+
+func (d *debugger) locals(paused *interpreter.Interpreter) {
+	scopes := paused.Frames()[0].Scopes()
+	shown := make(map[string]bool)
+	// The last scope is the globals
+	for _, scope := range scopes[:len(scopes)-1] {
+		for _, name := range interpreter.Names(scope) {
+			if !shown[name] {
+				fmt.Printf("%s = %s\n", name, interpreter.Stringify(scope[name]))
+				shown[name] = true
+			}
+		}
+	}
+	if len(shown) == 0 {
+		fmt.Println("No locals.")
+	}
+}
@@ -1,25 +1,68 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
 	"os"
 	//"strings"
 	"github.com/reilandeubank/golox/pkg/scanner"
 	//"github.com/reilandeubank/golox/pkg/expression"
+	"github.com/reilandeubank/golox/pkg/cover"
 	"github.com/reilandeubank/golox/pkg/interpreter"
 	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/trace"
 )
 
 var i interpreter.Interpreter = interpreter.NewInterpreter()
+
+// options are what i was built with, so that :reset can build it the same way again
+var options []interpreter.Option
+
+// setInterpreter replaces i with a fresh interpreter built with opts
+func setInterpreter(opts ...interpreter.Option) {
+	options = opts
+	i = interpreter.NewInterpreter(opts...)
+}
+
+var dumpAST = flag.Bool("dump-ast", false, "print the syntax tree of script as S-expressions instead of running it")
+var dumpTokens = flag.Bool("dump-tokens", false, "print the tokens scanned from script as a table instead of running it")
+var traceRun = flag.Bool("trace", false, "print each statement executed, with its line and environment depth, to stderr")
+var profileRun = flag.Bool("profile", false, "print a report of where script spent its time to stderr and write a pprof profile")
+var profileFile = flag.String("profile-file", "golox.pprof", "the file --profile writes its pprof profile to")
+var coverageFile = flag.String("coverage", "", "record which statements and branches run and write the coverage profile to this file")
+
 func main() {
-	args := os.Args[1:]
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			command(os.Args[2:])
+			return
+		}
+	}
 
-	if len(args) > 1 {
-		fmt.Println("Usage: golox [script]")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: golox [flags] [script]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       golox tokens|parse|exec|fmt|vet|debug [flags] file")
+		fmt.Fprintln(flag.CommandLine.Output(), "       golox cover [-html out.html] file.cov...")
+		fmt.Fprintln(flag.CommandLine.Output(), "       golox test [-run regexp] [-v] [file or directory...]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       golox bench [-count n] [-benchfmt] [file or directory...]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       golox lsp|dap")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	args := flag.Args()
+
+	if len(args) > 1 || (len(args) == 0 && (*dumpAST || *dumpTokens || *traceRun || *profileRun || *coverageFile != "")) {
+		flag.Usage()
 		os.Exit(64)
 	} else if len(args) == 1 {
-		err := runFile(args[0])
+		var err error
+		if *dumpTokens {
+			err = dumpFileTokens(args[0])
+		} else if *dumpAST {
+			err = dumpFile(args[0])
+		} else {
+			err = runFile(args[0])
+		}
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(64)
@@ -34,53 +77,118 @@ func runFile(path string) error {
 	if err != nil {
 		return err
 	}
+	source := string(bytes)
 
-	err = run(string(bytes))
-
-	if scanner.HadError() {
+	statements, ok := parseProgram(source)
+	if !ok {
 		os.Exit(65)
 	}
+
+	var hooks []interpreter.Option
+	if *traceRun {
+		hooks = append(hooks, interpreter.WithHook(trace.NewTracer(os.Stderr, source)))
+	}
+	var profiler *trace.Profiler
+	if *profileRun {
+		profiler = trace.NewProfiler()
+		hooks = append(hooks, interpreter.WithHook(profiler))
+	}
+	var collector *cover.Collector
+	if *coverageFile != "" {
+		collector = cover.NewCollector(path, statements)
+		hooks = append(hooks, interpreter.WithHook(collector))
+	}
+	if len(hooks) > 0 {
+		setInterpreter(hooks...)
+	}
+
+	err = i.Interpret(statements)
+
+	if profiler != nil {
+		profiler.Stop()
+		if err := writeProfile(profiler, path); err != nil {
+			return err
+		}
+	}
+	if collector != nil {
+		if err := writeCoverage(collector.Profile()); err != nil {
+			return err
+		}
+	}
 	if err != nil {
-		fmt.Println(err)
 		os.Exit(70)
 	}
 	return nil
 }
 
-func runPrompt() {
-	bufscanner := bufio.NewScanner(os.Stdin)
-
-	for {
-		fmt.Print(">>> ")
-		if !bufscanner.Scan() {
-			break
-		}
-
-		line := bufscanner.Text()
-		run(line)
-		scanner.SetErrorFlag(false)
+// writeCoverage saves a coverage profile to the file named by --coverage
+func writeCoverage(profile *cover.Profile) error {
+	file, err := os.Create(*coverageFile)
+	if err != nil {
+		return err
 	}
+	defer file.Close()
+	return cover.WriteProfiles(file, profile)
+}
 
-	if bufscanner.Err() != nil {
-		fmt.Println("An error occurred:", bufscanner.Err())
+// writeProfile reports the profile of the script at path to stderr and saves it for pprof
+func writeProfile(profiler *trace.Profiler, path string) error {
+	profiler.WriteReport(os.Stderr)
+	file, err := os.Create(*profileFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := profiler.WriteProfile(file, path); err != nil {
+		return err
 	}
+	fmt.Fprintf(os.Stderr, "\nProfile written to %s (view it with go tool pprof %s)\n", *profileFile, *profileFile)
+	return nil
 }
 
-func run(source string) error {
-	thisScanner := scanner.NewScanner(source)
-	tokens := thisScanner.ScanTokens()
-
-	parser := parser.NewParser(tokens)
-	statements, err := parser.Parse()
-
+// dumpFile prints the syntax tree of each top-level statement in the file at path
+func dumpFile(path string) error {
+	bytes, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
 
-	err = i.Interpret(statements)
+	for _, stmt := range parseSource(string(bytes)) {
+		fmt.Println(parser.ASTPrinter{}.PrintStmt(stmt))
+	}
+	return nil
+}
+
+// dumpFileTokens prints a table of the tokens in the file at path
+func dumpFileTokens(path string) error {
+	bytes, err := os.ReadFile(path)
 	if err != nil {
-		os.Exit(70)
 		return err
 	}
+
+	thisScanner := scanner.NewScanner(string(bytes))
+	tokens := thisScanner.ScanTokens()
+	if len(thisScanner.Errors) > 0 {
+		os.Exit(65)
+	}
+	printTokenTable(tokens)
 	return nil
 }
+
+// parseProgram scans and parses source, reporting any syntax errors
+func parseProgram(source string) ([]parser.Stmt, bool) {
+	thisScanner := scanner.NewScanner(source)
+	tokens := thisScanner.ScanTokens()
+
+	thisParser := parser.NewParser(tokens)
+	statements, err := thisParser.Parse()
+	return statements, err == nil && len(thisScanner.Errors) == 0 && len(thisParser.Errors) == 0
+}
+
+// run scans, parses and interprets source. Errors are reported by the scanner, parser
+// and interpreter themselves
+func run(source string) {
+	if statements, ok := parseProgram(source); ok {
+		i.Interpret(statements)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/reilandeubank/golox/pkg/interpreter"
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+const metaHelp = `:help           show this message
+:env            list the bindings in the global environment
+:load <file>    run a file in the current session
+:reset          discard all definitions and start a fresh interpreter
+:ast <code>     print the parsed tree of an expression or statements
+:tokens <code>  print the tokens scanned from code
+:time <code>    run code and report how long it took`
+
+// runMeta handles a REPL line starting with ':'
+func runMeta(line string) {
+	command, arg, _ := strings.Cut(strings.TrimPrefix(line, ":"), " ")
+	arg = strings.TrimSpace(arg)
+
+	switch command {
+	case "help":
+		fmt.Println(metaHelp)
+	case "env":
+		globals := i.Globals()
+		names := make([]string, 0, len(globals))
+		for name := range globals {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%s = %s\n", name, interpreter.Stringify(globals[name]))
+		}
+	case "load":
+		bytes, err := os.ReadFile(arg)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		run(string(bytes))
+	case "reset":
+		setInterpreter(options...)
+	case "ast":
+		if expr, ok := parseExpression(arg); ok {
+			fmt.Println(parser.ASTPrinter{}.Print(expr))
+			return
+		}
+		thisScanner := scanner.NewScanner(arg)
+		thisParser := parser.NewParser(thisScanner.ScanTokens())
+		statements, err := thisParser.Parse()
+		if err != nil {
+			return
+		}
+		for _, stmt := range statements {
+			fmt.Println(parser.ASTPrinter{}.PrintStmt(stmt))
+		}
+	case "tokens":
+		thisScanner := scanner.NewScanner(arg)
+		for _, token := range thisScanner.ScanTokens() {
+			fmt.Println(token)
+		}
+	case "time":
+		start := time.Now()
+		if expr, ok := parseExpression(arg); ok {
+			echo(expr)
+		} else {
+			run(arg)
+		}
+		fmt.Printf("took %v\n", time.Since(start))
+	default:
+		fmt.Printf("Unknown command ':%s'. Type :help for a list of commands.\n", command)
+	}
+}
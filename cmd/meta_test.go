@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reilandeubank/golox/pkg/interpreter"
+)
+
+func TestResetKeepsOptions(t *testing.T) {
+	defer setInterpreter()
+	var stdout, stderr strings.Builder
+	setInterpreter(interpreter.WithStdout(&stdout), interpreter.WithStderr(&stderr), interpreter.WithStepLimit(100))
+
+	run("var x = 1;")
+	runMeta(":reset")
+	if _, ok := i.Globals()["x"]; ok {
+		t.Error(":reset kept x")
+	}
+
+	run("print 2;")
+	if stdout.String() != "2\n" {
+		t.Errorf("printed %q after :reset, want %q", stdout.String(), "2\n")
+	}
+	run("while (true) {}")
+	if want := "step limit of 100 exceeded"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("reported %q after :reset, want the step limit to still apply", stderr.String())
+	}
+}
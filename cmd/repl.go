@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/reilandeubank/golox/pkg/interpreter"
+	"github.com/reilandeubank/golox/pkg/lineedit"
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+func runPrompt() {
+	editor := lineedit.New(os.Stdin, os.Stdout)
+	editor.Complete = complete
+	if home, err := os.UserHomeDir(); err == nil {
+		editor.SetHistoryFile(filepath.Join(home, ".golox_history"))
+	}
+	defer editor.Close()
+
+	var source strings.Builder
+
+	for {
+		prompt := ">>> "
+		if source.Len() > 0 {
+			prompt = "... "
+		}
+		line, err := editor.Prompt(prompt)
+		if err == lineedit.ErrInterrupted {
+			source.Reset()
+			continue
+		} else if err == io.EOF {
+			break
+		} else if err != nil {
+			fmt.Println("An error occurred:", err)
+			break
+		}
+		editor.AddHistory(line)
+
+		if source.Len() == 0 && strings.HasPrefix(line, ":") {
+			runMeta(line)
+			continue
+		}
+
+		if source.Len() > 0 {
+			source.WriteString("\n")
+		}
+		source.WriteString(line)
+
+		expr, isExpr := parseExpression(source.String())
+
+		// A blank line submits whatever has been typed, so a mistake can't trap the prompt
+		if !isExpr && line != "" && incomplete(source.String()) {
+			continue
+		}
+
+		if isExpr {
+			echo(expr)
+		} else {
+			run(source.String())
+		}
+		source.Reset()
+	}
+}
+
+// complete offers keywords and every name the interpreter can currently see
+func complete(word string) []string {
+	return append(scanner.Keywords(), i.Names()...)
+}
+
+// parseExpression parses source as a bare expression, as typed at the prompt without a
+// trailing ';'. Input that is already a valid program is left for run
+func parseExpression(source string) (parser.Expression, bool) {
+	thisScanner := quietScanner(source)
+	tokens := thisScanner.ScanTokens()
+	if len(thisScanner.Errors) > 0 {
+		return nil, false
+	}
+
+	thisParser := quietParser(tokens)
+	if _, err := thisParser.Parse(); err == nil {
+		return nil, false
+	}
+
+	thisParser = quietParser(tokens)
+	expr, err := thisParser.ParseExpression()
+	return expr, err == nil
+}
+
+// echo evaluates expr, prints its value and binds it to _
+func echo(expr parser.Expression) {
+	value, err := i.Evaluate(expr)
+	if err != nil {
+		return
+	}
+	fmt.Println(interpreter.Stringify(value))
+	i.Define("_", value)
+}
+
+// quietScanner and quietParser don't report errors, for speculative parses of REPL input
+func quietScanner(source string) scanner.Scanner {
+	thisScanner := scanner.NewScanner(source)
+	thisScanner.ErrorOutput = io.Discard
+	return thisScanner
+}
+
+func quietParser(tokens []scanner.Token) parser.Parser {
+	thisParser := parser.NewParser(tokens)
+	thisParser.ErrorOutput = io.Discard
+	return thisParser
+}
+
+// incomplete reports whether source stops partway through a statement: it has unclosed
+// braces, brackets or parentheses, an unterminated string, or a parse error at the end of input
+func incomplete(source string) bool {
+	thisScanner := quietScanner(source)
+	tokens := thisScanner.ScanTokens()
+	if thisScanner.UnterminatedString {
+		return true
+	}
+
+	depth := 0
+	for _, token := range tokens {
+		switch token.Type {
+		case scanner.LEFT_PAREN, scanner.LEFT_BRACE, scanner.LEFT_BRACKET:
+			depth++
+		case scanner.RIGHT_PAREN, scanner.RIGHT_BRACE, scanner.RIGHT_BRACKET:
+			depth--
+		}
+	}
+	if depth != 0 {
+		return depth > 0
+	}
+
+	thisParser := quietParser(tokens)
+	_, err := thisParser.Parse()
+	var syntaxErr *parser.SyntaxError
+	return errors.As(err, &syntaxErr) && syntaxErr.Token.Type == scanner.EOF
+}
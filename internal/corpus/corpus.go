@@ -0,0 +1,67 @@
+// Package corpus gives the tests of the packages under pkg/ the Lox programs kept in the
+// repository, wherever the tests run from.
+package corpus
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// Program is one of the repository's Lox programs
+type Program struct {
+	Name   string // the file name without .lox
+	Source string
+}
+
+// root returns the repository's root directory
+func root() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..")
+}
+
+// Seeds adds every .lox file in the repository's testing/ and test/ directories, then
+// extra, to a fuzz test's seed corpus
+func Seeds(f *testing.F, extra ...string) {
+	f.Helper()
+	for _, dir := range []string{"testing", "test"} {
+		err := filepath.WalkDir(filepath.Join(root(), dir), func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() || filepath.Ext(path) != ".lox" {
+				return err
+			}
+			source, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			f.Add(string(source))
+			return nil
+		})
+		if err != nil {
+			f.Fatal(err)
+		}
+	}
+	for _, source := range extra {
+		f.Add(source)
+	}
+}
+
+// Benchmarks returns the workloads in the repository's bench/ directory
+func Benchmarks(tb testing.TB) []Program {
+	tb.Helper()
+	paths, err := filepath.Glob(filepath.Join(root(), "bench", "*.lox"))
+	if err != nil || len(paths) == 0 {
+		tb.Fatal("no benchmark files found", err)
+	}
+	var programs []Program
+	for _, path := range paths {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		programs = append(programs, Program{strings.TrimSuffix(filepath.Base(path), ".lox"), string(source)})
+	}
+	return programs
+}
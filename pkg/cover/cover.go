@@ -0,0 +1,424 @@
+// Package cover records which statements and branches of a Lox program run, saves the
+// results as coverage profiles and reports on them.
+package cover
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/reilandeubank/golox/pkg/interpreter"
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+// Branch kinds
+const (
+	KindIf  = "if"
+	KindAnd = "and"
+	KindOr  = "or"
+)
+
+// Position is where a statement or branch point starts in its file
+type Position struct {
+	Line   int
+	Column int
+}
+
+// Branch counts how often each arm of a branch point was taken. For an if statement the
+// arms are the then and else branches (taken even when there is no else clause); for
+// 'and' and 'or' they are evaluating the right operand and short-circuiting past it
+type Branch struct {
+	Line   int
+	Column int
+	Kind   string
+	Counts [2]int
+}
+
+// Arms names a branch's two arms
+func (b Branch) Arms() [2]string {
+	if b.Kind == KindIf {
+		return [2]string{"then", "else"}
+	}
+	return [2]string{"right operand", "short-circuit"}
+}
+
+// Profile is the coverage of one source file
+type Profile struct {
+	File       string
+	Statements map[Position]int // how often each statement ran
+	Branches   []*Branch        // ordered by position
+}
+
+// Summary is a profile's coverage as fractions of what could have been covered
+type Summary struct {
+	Statements, CoveredStatements int
+	Arms, CoveredArms             int
+}
+
+func percent(covered, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(covered)/float64(total))
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("statements %s (%d/%d), branches %s (%d/%d)",
+		percent(s.CoveredStatements, s.Statements), s.CoveredStatements, s.Statements,
+		percent(s.CoveredArms, s.Arms), s.CoveredArms, s.Arms)
+}
+
+func (p *Profile) Summary() Summary {
+	var s Summary
+	for _, count := range p.Statements {
+		s.Statements++
+		if count > 0 {
+			s.CoveredStatements++
+		}
+	}
+	for _, branch := range p.Branches {
+		for _, count := range branch.Counts {
+			s.Arms++
+			if count > 0 {
+				s.CoveredArms++
+			}
+		}
+	}
+	return s
+}
+
+// Collector is an interpreter.Hook and interpreter.BranchHook that fills in a Profile
+type Collector struct {
+	profile  *Profile
+	branches map[Position]*Branch
+}
+
+// NewCollector returns a collector for the program statements parsed from file. Every
+// statement and branch in the program starts with a count of zero
+func NewCollector(file string, statements []parser.Stmt) *Collector {
+	c := &Collector{
+		profile:  &Profile{File: file, Statements: make(map[Position]int)},
+		branches: make(map[Position]*Branch),
+	}
+	r := registrar{c}
+	for _, stmt := range statements {
+		r.stmt(stmt)
+	}
+	sort.Slice(c.profile.Branches, func(a, b int) bool {
+		return less(c.profile.Branches[a], c.profile.Branches[b])
+	})
+	return c
+}
+
+func less(a, b *Branch) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Column < b.Column
+}
+
+// Profile returns the coverage collected so far
+func (c *Collector) Profile() *Profile {
+	return c.profile
+}
+
+func (c *Collector) branch(line int, column int, kind string) *Branch {
+	key := Position{line, column}
+	b, ok := c.branches[key]
+	if !ok {
+		b = &Branch{Line: line, Column: column, Kind: kind}
+		c.branches[key] = b
+		c.profile.Branches = append(c.profile.Branches, b)
+	}
+	return b
+}
+
+func (c *Collector) BeforeStmt(i *interpreter.Interpreter, stmt parser.Stmt) error {
+	if _, ok := stmt.(parser.BlockStmt); ok {
+		return nil
+	}
+	if line, column := parser.StmtPosition(stmt); line != 0 {
+		c.profile.Statements[Position{line, column}]++
+	}
+	return nil
+}
+
+func (c *Collector) EnterCall(i *interpreter.Interpreter, function interpreter.LoxFunction) {}
+
+func (c *Collector) ExitCall(i *interpreter.Interpreter, function interpreter.LoxFunction) {}
+
+func (c *Collector) IfBranch(i *interpreter.Interpreter, stmt parser.IfStmt, then bool) {
+	arm := 1
+	if then {
+		arm = 0
+	}
+	line, column := parser.ExprPosition(stmt.Condition)
+	c.branch(line, column, KindIf).Counts[arm]++
+}
+
+func (c *Collector) LogicalBranch(i *interpreter.Interpreter, expr parser.Logical, right bool) {
+	arm := 1
+	if right {
+		arm = 0
+	}
+	c.branch(expr.Operator.Line, expr.Operator.Column, logicalKind(expr)).Counts[arm]++
+}
+
+func logicalKind(expr parser.Logical) string {
+	if expr.Operator.Type == scanner.OR {
+		return KindOr
+	}
+	return KindAnd
+}
+
+// registrar walks a program registering every statement and branch point
+type registrar struct {
+	c *Collector
+}
+
+func (r registrar) stmt(stmt parser.Stmt) {
+	if stmt != nil {
+		stmt.Accept(r)
+	}
+}
+
+func (r registrar) expr(expr parser.Expression) {
+	if expr != nil {
+		expr.Accept(r)
+	}
+}
+
+func (r registrar) statement(stmt parser.Stmt) {
+	if line, column := parser.StmtPosition(stmt); line != 0 {
+		r.c.profile.Statements[Position{line, column}] += 0
+	}
+}
+
+func (r registrar) VisitExprStmt(e parser.ExprStmt) (interface{}, error) {
+	r.statement(e)
+	r.expr(e.Expression)
+	return nil, nil
+}
+
+func (r registrar) VisitPrintStmt(p parser.PrintStmt) (interface{}, error) {
+	r.statement(p)
+	r.expr(p.Expression)
+	return nil, nil
+}
+
+func (r registrar) VisitVarStmt(v parser.VarStmt) (interface{}, error) {
+	r.statement(v)
+	r.expr(v.Initializer)
+	return nil, nil
+}
+
+func (r registrar) VisitBlockStmt(b parser.BlockStmt) (interface{}, error) {
+	for _, stmt := range b.Statements {
+		r.stmt(stmt)
+	}
+	return nil, nil
+}
+
+func (r registrar) VisitIfStmt(i parser.IfStmt) (interface{}, error) {
+	r.statement(i)
+	line, column := parser.ExprPosition(i.Condition)
+	r.c.branch(line, column, KindIf)
+	r.expr(i.Condition)
+	r.stmt(i.ThenBranch)
+	r.stmt(i.ElseBranch)
+	return nil, nil
+}
+
+func (r registrar) VisitWhileStmt(w parser.WhileStmt) (interface{}, error) {
+	r.statement(w)
+	r.expr(w.Condition)
+	r.stmt(w.Body)
+	return nil, nil
+}
+
+func (r registrar) VisitFunctionStmt(f parser.FunctionStmt) (interface{}, error) {
+	r.statement(f)
+	for _, stmt := range f.Body {
+		r.stmt(stmt)
+	}
+	return nil, nil
+}
+
+func (r registrar) VisitReturnStmt(ret parser.ReturnStmt) (interface{}, error) {
+	r.statement(ret)
+	r.expr(ret.Value)
+	return nil, nil
+}
+
+func (r registrar) VisitBinaryExpr(b parser.Binary) (interface{}, error) {
+	r.expr(b.Left)
+	r.expr(b.Right)
+	return nil, nil
+}
+
+func (r registrar) VisitGroupingExpr(g parser.Grouping) (interface{}, error) {
+	r.expr(g.Expression)
+	return nil, nil
+}
+
+func (r registrar) VisitLiteralExpr(l parser.Literal) (interface{}, error) {
+	return nil, nil
+}
+
+func (r registrar) VisitUnaryExpr(u parser.Unary) (interface{}, error) {
+	r.expr(u.Right)
+	return nil, nil
+}
+
+func (r registrar) VisitVariableExpr(v parser.Variable) (interface{}, error) {
+	return nil, nil
+}
+
+func (r registrar) VisitAssignExpr(a parser.Assign) (interface{}, error) {
+	r.expr(a.Value)
+	return nil, nil
+}
+
+func (r registrar) VisitLogicalExpr(l parser.Logical) (interface{}, error) {
+	r.c.branch(l.Operator.Line, l.Operator.Column, logicalKind(l))
+	r.expr(l.Left)
+	r.expr(l.Right)
+	return nil, nil
+}
+
+func (r registrar) VisitCallExpr(c parser.Call) (interface{}, error) {
+	r.expr(c.Callee)
+	for _, argument := range c.Arguments {
+		r.expr(argument)
+	}
+	return nil, nil
+}
+
+func (r registrar) VisitIndexExpr(i parser.Index) (interface{}, error) {
+	r.expr(i.Object)
+	r.expr(i.Index)
+	return nil, nil
+}
+
+// The profile format is line based:
+//
+//	mode: golox
+//	file: <path>
+//	stmt <line> <column> <count>
+//	branch <line> <column> <kind> <count> <count>
+//
+// with a file line starting the records for each file
+const header = "mode: golox"
+
+// WriteProfiles writes profiles in the coverage file format
+func WriteProfiles(w io.Writer, profiles ...*Profile) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, header)
+	for _, p := range profiles {
+		fmt.Fprintf(out, "file: %s\n", p.File)
+		for _, pos := range p.positions() {
+			fmt.Fprintf(out, "stmt %d %d %d\n", pos.Line, pos.Column, p.Statements[pos])
+		}
+		for _, b := range p.Branches {
+			fmt.Fprintf(out, "branch %d %d %s %d %d\n", b.Line, b.Column, b.Kind, b.Counts[0], b.Counts[1])
+		}
+	}
+	return out.Flush()
+}
+
+// ReadProfiles parses a coverage file. Records for a file that appears more than once,
+// as when several runs are concatenated, are added together
+func ReadProfiles(r io.Reader) ([]*Profile, error) {
+	in := bufio.NewScanner(r)
+	var profiles []*Profile
+	byFile := make(map[string]*Profile)
+	var current *Profile
+	lineNumber := 0
+	for in.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(in.Text())
+		if line == "" || line == header {
+			continue
+		}
+		fail := func(format string, args ...interface{}) error {
+			return fmt.Errorf("coverage line %d: %s", lineNumber, fmt.Sprintf(format, args...))
+		}
+
+		if file, ok := strings.CutPrefix(line, "file: "); ok {
+			current = byFile[file]
+			if current == nil {
+				current = &Profile{File: file, Statements: make(map[Position]int)}
+				byFile[file] = current
+				profiles = append(profiles, current)
+			}
+			continue
+		}
+		if current == nil {
+			return nil, fail("record before any file")
+		}
+
+		fields := strings.Fields(line)
+		numbers := func(fields ...string) ([]int, error) {
+			values := make([]int, len(fields))
+			for j, field := range fields {
+				value, err := strconv.Atoi(field)
+				if err != nil {
+					return nil, fail("invalid number %q", field)
+				}
+				values[j] = value
+			}
+			return values, nil
+		}
+		switch {
+		case fields[0] == "stmt" && len(fields) == 4:
+			values, err := numbers(fields[1:]...)
+			if err != nil {
+				return nil, err
+			}
+			current.Statements[Position{values[0], values[1]}] += values[2]
+		case fields[0] == "branch" && len(fields) == 6:
+			values, err := numbers(fields[1], fields[2], fields[4], fields[5])
+			if err != nil {
+				return nil, err
+			}
+			current.addBranch(Branch{Line: values[0], Column: values[1], Kind: fields[3], Counts: [2]int{values[2], values[3]}})
+		default:
+			return nil, fail("unknown record %q", line)
+		}
+	}
+	if err := in.Err(); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// positions returns the positions of the profile's statements in order
+func (p *Profile) positions() []Position {
+	positions := make([]Position, 0, len(p.Statements))
+	for pos := range p.Statements {
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(a, b int) bool {
+		if positions[a].Line != positions[b].Line {
+			return positions[a].Line < positions[b].Line
+		}
+		return positions[a].Column < positions[b].Column
+	})
+	return positions
+}
+
+func (p *Profile) addBranch(b Branch) {
+	for _, existing := range p.Branches {
+		if existing.Line == b.Line && existing.Column == b.Column {
+			existing.Counts[0] += b.Counts[0]
+			existing.Counts[1] += b.Counts[1]
+			return
+		}
+	}
+	p.Branches = append(p.Branches, &b)
+	sort.SliceStable(p.Branches, func(a, c int) bool { return less(p.Branches[a], p.Branches[c]) })
+}
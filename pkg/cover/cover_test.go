@@ -0,0 +1,134 @@
+package cover
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/reilandeubank/golox/pkg/interpreter"
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+// collect runs source and returns its coverage
+func collect(t *testing.T, source string) *Profile {
+	t.Helper()
+	thisScanner := scanner.NewScanner(source)
+	thisParser := parser.NewParser(thisScanner.ScanTokens())
+	statements, err := thisParser.Parse()
+	if err != nil || len(thisScanner.Errors) > 0 || len(thisParser.Errors) > 0 {
+		t.Fatalf("%q doesn't parse", source)
+	}
+	collector := NewCollector("test.lox", statements)
+	i := interpreter.NewInterpreter(interpreter.WithHook(collector), interpreter.WithStdout(io.Discard))
+	if err := i.Interpret(statements); err != nil {
+		t.Fatal(err)
+	}
+	return collector.Profile()
+}
+
+func TestStatementsSharingALine(t *testing.T) {
+	p := collect(t, "fun f(x) { if (x > 1) return 1; return 2; }\nf(2);\n")
+	want := map[Position]int{
+		{1, 5}:  1, // fun f
+		{1, 16}: 1, // if
+		{1, 23}: 1, // return 1
+		{1, 33}: 0, // return 2
+		{2, 1}:  1, // f(2)
+	}
+	if !reflect.DeepEqual(p.Statements, want) {
+		t.Errorf("got statements %v, want %v", p.Statements, want)
+	}
+	if s := p.Summary(); s.Statements != 5 || s.CoveredStatements != 4 {
+		t.Errorf("got summary %v, want 4 of 5 statements covered", s)
+	}
+}
+
+func TestBranchesSharingALine(t *testing.T) {
+	p := collect(t, "var a = true;\nif (a) print 1; if (!a) print 2;\nprint a and false or a;\n")
+	want := []Branch{
+		{Line: 2, Column: 5, Kind: KindIf, Counts: [2]int{1, 0}},
+		{Line: 2, Column: 21, Kind: KindIf, Counts: [2]int{0, 1}},
+		{Line: 3, Column: 9, Kind: KindAnd, Counts: [2]int{1, 0}},
+		{Line: 3, Column: 19, Kind: KindOr, Counts: [2]int{1, 0}},
+	}
+	if len(p.Branches) != len(want) {
+		t.Fatalf("got %d branches, want %d", len(p.Branches), len(want))
+	}
+	for j, b := range p.Branches {
+		if *b != want[j] {
+			t.Errorf("got branch %+v, want %+v", *b, want[j])
+		}
+	}
+	if s := p.Summary(); s.Arms != 8 || s.CoveredArms != 4 {
+		t.Errorf("got summary %v, want 4 of 8 arms covered", s)
+	}
+}
+
+func TestProfileFormat(t *testing.T) {
+	p := collect(t, "var x = 1;\nif (x > 1) print x; else print -x;\n")
+
+	var out bytes.Buffer
+	if err := WriteProfiles(&out, p); err != nil {
+		t.Fatal(err)
+	}
+	want := "mode: golox\nfile: test.lox\nstmt 1 5 1\nstmt 2 5 1\nstmt 2 18 0\nstmt 2 32 1\nbranch 2 5 if 0 1\n"
+	if out.String() != want {
+		t.Errorf("got profile:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	// Profiles of the same file from several runs add up
+	profiles, err := ReadProfiles(strings.NewReader(want + want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 1 {
+		t.Fatalf("got %d profiles, want 1", len(profiles))
+	}
+	read := profiles[0]
+	if read.Statements[Position{2, 32}] != 2 || read.Statements[Position{2, 18}] != 0 || len(read.Statements) != 4 {
+		t.Errorf("got statements %v", read.Statements)
+	}
+	if len(read.Branches) != 1 || read.Branches[0].Counts != [2]int{0, 2} {
+		t.Errorf("got branches %v", read.Branches)
+	}
+}
+
+func TestReadProfilesErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"stmt 1 1 1\n", "coverage line 1: record before any file"},
+		{"file: a.lox\nstmt 1 x 1\n", `coverage line 2: invalid number "x"`},
+		{"file: a.lox\nstmt 1 1\n", `coverage line 2: unknown record "stmt 1 1"`},
+	}
+	for _, test := range tests {
+		_, err := ReadProfiles(strings.NewReader(test.input))
+		if err == nil || err.Error() != test.want {
+			t.Errorf("reading %q: got error %v, want %q", test.input, err, test.want)
+		}
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	source := "fun f(x) { if (x > 1) return 1; return 2; }\nf(2);\nvar unused = false;\nif (unused) print 1;\n"
+	p := collect(t, source)
+	var out bytes.Buffer
+	err := WriteHTML(&out, []*Profile{p}, func(file string) (string, error) { return source, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := out.String()
+	for _, want := range []string{
+		`<span class="line partial" title="3 of 4 statements run; if: then 1, else 0">`,
+		`<span class="line covered" title="statement run 1 times">`,
+		`<span class="line partial" title="1 of 2 statements run; if: then 0, else 1">`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page doesn't contain %s:\n%s", want, page)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package cover
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+var page = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>golox coverage</title>
+<style>
+body { font-family: sans-serif; margin: 1em 2em; }
+pre { font-family: monospace; line-height: 1.3; }
+.line { display: block; }
+.number { display: inline-block; width: 4em; color: #999; text-align: right; margin-right: 1em; user-select: none; }
+.covered { background: #d8f5d8; }
+.uncovered { background: #f9d0d0; }
+.partial { background: #f8efc0; }
+.legend span { padding: 0 .5em; margin-right: .5em; }
+</style>
+</head>
+<body>
+<p class="legend"><span class="covered">covered</span><span class="partial">partly covered</span><span class="uncovered">not covered</span></p>
+{{range .}}<h2>{{.File}}</h2>
+<p>{{.Summary}}</p>
+{{if .Error}}<p>{{.Error}}</p>{{else}}<pre>{{range .Lines}}<span class="line {{.Class}}"{{if .Title}} title="{{.Title}}"{{end}}><span class="number">{{.Number}}</span>{{.Text}}</span>{{end}}</pre>{{end}}
+{{end}}</body>
+</html>
+`))
+
+type htmlLine struct {
+	Number int
+	Text   string
+	Class  string
+	Title  string
+}
+
+type htmlFile struct {
+	File    string
+	Summary Summary
+	Error   string
+	Lines   []htmlLine
+}
+
+// WriteHTML writes a page showing the source of each profiled file with every line
+// colored by its coverage. source returns the text of a file
+func WriteHTML(w io.Writer, profiles []*Profile, source func(file string) (string, error)) error {
+	var files []htmlFile
+	for _, p := range profiles {
+		file := htmlFile{File: p.File, Summary: p.Summary()}
+		text, err := source(p.File)
+		if err != nil {
+			file.Error = err.Error()
+			files = append(files, file)
+			continue
+		}
+
+		statements := make(map[int][]int)
+		for pos, count := range p.Statements {
+			statements[pos.Line] = append(statements[pos.Line], count)
+		}
+		branches := make(map[int][]*Branch)
+		for _, b := range p.Branches {
+			branches[b.Line] = append(branches[b.Line], b)
+		}
+		for j, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+			number := j + 1
+			l := htmlLine{Number: number, Text: line}
+			var notes []string
+			if counts, ok := statements[number]; ok {
+				run := 0
+				for _, count := range counts {
+					if count > 0 {
+						run++
+					}
+				}
+				if len(counts) == 1 {
+					notes = append(notes, fmt.Sprintf("statement run %d times", counts[0]))
+				} else {
+					notes = append(notes, fmt.Sprintf("%d of %d statements run", run, len(counts)))
+				}
+				switch run {
+				case 0:
+					l.Class = "uncovered"
+				case len(counts):
+					l.Class = "covered"
+				default:
+					l.Class = "partial"
+				}
+			}
+			for _, b := range branches[number] {
+				arms := b.Arms()
+				notes = append(notes, fmt.Sprintf("%s: %s %d, %s %d", b.Kind, arms[0], b.Counts[0], arms[1], b.Counts[1]))
+				if l.Class == "covered" && (b.Counts[0] == 0 || b.Counts[1] == 0) {
+					l.Class = "partial"
+				}
+			}
+			l.Title = strings.Join(notes, "; ")
+			file.Lines = append(file.Lines, l)
+		}
+		files = append(files, file)
+	}
+	return page.Execute(w, files)
+}
@@ -0,0 +1,459 @@
+// Package dap implements a Debug Adapter Protocol server, letting editors run Lox programs
+// under the debugger in package debug.
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/reilandeubank/golox/pkg/debug"
+	"github.com/reilandeubank/golox/pkg/interpreter"
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+	"github.com/reilandeubank/golox/pkg/transport"
+)
+
+// resumption is sent to a paused program to say how it should carry on
+type resumption struct {
+	action debug.Action
+	err    error
+}
+
+// scopeRef identifies the scope a variablesReference stands for
+type scopeRef struct {
+	frame int
+	scope int
+}
+
+type Server struct {
+	in  *bufio.Reader
+	out *transport.Writer
+
+	seqMu sync.Mutex
+	seq   int
+
+	source     Source
+	statements []parser.Stmt
+	executable map[int]bool
+	controller *debug.Controller
+	resume     chan resumption
+	done       chan struct{} // closed when the program ends
+	started    bool
+
+	mu         sync.Mutex // guards the fields describing the current pause
+	halting    bool
+	stop       *debug.Stop
+	references map[int]scopeRef
+}
+
+// NewServer returns a server reading requests from in and writing responses and events
+// to out
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:     bufio.NewReader(in),
+		out:    transport.NewWriter(out),
+		resume: make(chan resumption),
+		done:   make(chan struct{}),
+	}
+}
+
+// Run serves requests until the client disconnects or closes the input, halting the
+// program if it is still running
+func (s *Server) Run() error {
+	defer s.halt()
+	for {
+		body, err := transport.ReadMessage(s.in)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			return fmt.Errorf("malformed message: %w", err)
+		}
+		result, err := s.handle(req)
+		if err != nil {
+			s.send(&response{Type: "response", RequestSeq: req.Seq, Command: req.Command, Message: err.Error()})
+			continue
+		}
+		s.send(&response{Type: "response", RequestSeq: req.Seq, Success: true, Command: req.Command, Body: result})
+
+		switch req.Command {
+		case "launch":
+			s.event("initialized", nil)
+		case "disconnect", "terminate":
+			return nil
+		}
+	}
+}
+
+func (s *Server) send(message interface{}) {
+	s.seqMu.Lock()
+	defer s.seqMu.Unlock()
+	s.seq++
+	switch m := message.(type) {
+	case *response:
+		m.Seq = s.seq
+	case *event:
+		m.Seq = s.seq
+	}
+	body, _ := json.Marshal(message)
+	s.out.WriteMessage(body)
+}
+
+func (s *Server) event(name string, body interface{}) {
+	s.send(&event{Type: "event", Event: name, Body: body})
+}
+
+func decode(arguments json.RawMessage, v interface{}) error {
+	if len(arguments) == 0 {
+		return nil
+	}
+	return json.Unmarshal(arguments, v)
+}
+
+func (s *Server) handle(req request) (interface{}, error) {
+	switch req.Command {
+	case "initialize":
+		return map[string]interface{}{
+			"supportsConfigurationDoneRequest": true,
+			"supportsEvaluateForHovers":        true,
+			"supportsTerminateRequest":         true,
+		}, nil
+	case "launch":
+		var args launchArguments
+		if err := decode(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return nil, s.launch(args)
+	case "setBreakpoints":
+		var args setBreakpointsArguments
+		if err := decode(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.setBreakpoints(args)
+	case "setExceptionBreakpoints":
+		return map[string]interface{}{"breakpoints": []Breakpoint{}}, nil
+	case "configurationDone":
+		return nil, s.start()
+	case "threads":
+		return map[string]interface{}{"threads": []Thread{{ID: threadID, Name: "main"}}}, nil
+
+	case "continue":
+		return map[string]interface{}{"allThreadsContinued": true}, s.resumeWith(debug.Continue)
+	case "next":
+		return nil, s.resumeWith(debug.Next)
+	case "stepIn":
+		return nil, s.resumeWith(debug.Step)
+	case "stepOut":
+		return nil, s.resumeWith(debug.Finish)
+	case "pause":
+		if s.controller == nil {
+			return nil, errors.New("no program has been launched")
+		}
+		s.controller.Interrupt()
+		return nil, nil
+
+	case "stackTrace":
+		return s.stackTrace()
+	case "scopes":
+		var args frameArguments
+		if err := decode(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.scopes(args.FrameID)
+	case "variables":
+		var args variablesArguments
+		if err := decode(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.variables(args.VariablesReference)
+	case "evaluate":
+		var args evaluateArguments
+		if err := decode(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.evaluate(args)
+
+	case "disconnect", "terminate":
+		s.halt()
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported command '%s'", req.Command)
+}
+
+func (s *Server) launch(args launchArguments) error {
+	if s.controller != nil {
+		return errors.New("a program has already been launched")
+	}
+	bytes, err := os.ReadFile(args.Program)
+	if err != nil {
+		return err
+	}
+	thisScanner := scanner.NewScanner(string(bytes))
+	thisScanner.ErrorOutput = io.Discard
+	tokens := thisScanner.ScanTokens()
+	if len(thisScanner.Errors) > 0 {
+		scanErr := thisScanner.Errors[0]
+		return fmt.Errorf("%s:%d: %s", args.Program, scanErr.Line, scanErr.Message)
+	}
+	thisParser := parser.NewParser(tokens)
+	thisParser.ErrorOutput = io.Discard
+	statements, err := thisParser.Parse()
+	if len(thisParser.Errors) > 0 {
+		syntaxErr := thisParser.Errors[0]
+		return fmt.Errorf("%s:%d: %s", args.Program, syntaxErr.Token.Line, syntaxErr.Message)
+	} else if err != nil {
+		return err
+	}
+
+	s.source = Source{Name: filepath.Base(args.Program), Path: args.Program}
+	s.statements = statements
+	s.executable = debug.Lines(statements)
+	s.controller = debug.NewController(args.StopOnEntry, s.pause)
+	return nil
+}
+
+func (s *Server) setBreakpoints(args setBreakpointsArguments) (interface{}, error) {
+	if s.controller == nil {
+		return nil, errors.New("no program has been launched")
+	}
+	s.controller.ClearBreakpoints()
+	breakpoints := make([]Breakpoint, len(args.Breakpoints))
+	for j, requested := range args.Breakpoints {
+		breakpoints[j] = Breakpoint{Line: requested.Line, Verified: s.executable[requested.Line]}
+		if breakpoints[j].Verified {
+			s.controller.SetBreakpoint(requested.Line, true)
+		} else {
+			breakpoints[j].Message = "no statement on this line"
+		}
+	}
+	return map[string]interface{}{"breakpoints": breakpoints}, nil
+}
+
+// output sends what the program writes to the client as output events
+type output struct {
+	server   *Server
+	category string
+}
+
+func (o output) Write(p []byte) (int, error) {
+	o.server.event("output", map[string]string{"category": o.category, "output": string(p)})
+	return len(p), nil
+}
+
+// start runs the launched program on its own goroutine
+func (s *Server) start() error {
+	if s.controller == nil {
+		return errors.New("no program has been launched")
+	}
+	if s.started {
+		return nil
+	}
+	s.started = true
+
+	debugged := interpreter.NewInterpreter(
+		interpreter.WithStdout(output{s, "stdout"}),
+		interpreter.WithStderr(output{s, "stderr"}),
+		interpreter.WithStdin(strings.NewReader("")),
+		interpreter.WithHook(s.controller),
+	)
+	go func() {
+		defer close(s.done)
+		err := debugged.Interpret(s.statements)
+		exitCode := 0
+		if err != nil && !errors.Is(err, interpreter.ErrHalted) {
+			exitCode = 70
+		}
+		s.event("exited", map[string]int{"exitCode": exitCode})
+		s.event("terminated", nil)
+	}()
+	return nil
+}
+
+// pause is called on the program's goroutine each time it stops
+func (s *Server) pause(stop debug.Stop) (debug.Action, error) {
+	s.mu.Lock()
+	if s.halting {
+		s.mu.Unlock()
+		return debug.Continue, interpreter.ErrHalted
+	}
+	s.stop = &stop
+	s.references = make(map[int]scopeRef)
+	s.mu.Unlock()
+
+	s.event("stopped", map[string]interface{}{"reason": stop.Reason, "threadId": threadID, "allThreadsStopped": true})
+	r := <-s.resume
+
+	s.mu.Lock()
+	s.stop = nil
+	s.mu.Unlock()
+	return r.action, r.err
+}
+
+// paused returns the current pause, or an error if the program is running
+func (s *Server) paused() (*debug.Stop, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop == nil {
+		return nil, errors.New("the program is not paused")
+	}
+	return s.stop, nil
+}
+
+func (s *Server) resumeWith(action debug.Action) error {
+	if _, err := s.paused(); err != nil {
+		return err
+	}
+	s.resume <- resumption{action: action}
+	return nil
+}
+
+// halt stops the program, if it was started, and waits for it to end
+func (s *Server) halt() {
+	if !s.started {
+		return
+	}
+	s.mu.Lock()
+	halting, paused := s.halting, s.stop != nil
+	s.halting = true
+	s.mu.Unlock()
+	if halting {
+		return
+	}
+
+	// A running program sees the interrupt at its next statement and halts there
+	s.controller.Interrupt()
+	if paused {
+		s.resume <- resumption{err: interpreter.ErrHalted}
+	}
+	<-s.done
+}
+
+func (s *Server) frame(id int) (*debug.Stop, interpreter.Frame, error) {
+	stop, err := s.paused()
+	if err != nil {
+		return nil, interpreter.Frame{}, err
+	}
+	frames := stop.Interpreter.Frames()
+	if id < 1 || id > len(frames) {
+		return nil, interpreter.Frame{}, fmt.Errorf("unknown frame %d", id)
+	}
+	return stop, frames[id-1], nil
+}
+
+// Frame IDs count from 1 for the innermost frame of the current pause
+func (s *Server) stackTrace() (interface{}, error) {
+	stop, err := s.paused()
+	if err != nil {
+		return nil, err
+	}
+	frames := stop.Interpreter.Frames()
+	stackFrames := make([]StackFrame, len(frames))
+	for j, frame := range frames {
+		source := s.source
+		stackFrames[j] = StackFrame{ID: j + 1, Name: frame.Name, Source: &source, Line: frame.Line, Column: 1}
+	}
+	return map[string]interface{}{"stackFrames": stackFrames, "totalFrames": len(stackFrames)}, nil
+}
+
+// scopes lists one DAP scope per environment in the frame's chain
+func (s *Server) scopes(frameID int) (interface{}, error) {
+	_, frame, err := s.frame(frameID)
+	if err != nil {
+		return nil, err
+	}
+	environments := frame.Scopes()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	scopes := make([]Scope, len(environments))
+	for j := range environments {
+		name := "Locals"
+		if j == len(environments)-1 {
+			name = "Globals"
+		} else if j > 0 {
+			name = fmt.Sprintf("Enclosing (%d)", j)
+		}
+		reference := len(s.references) + 1
+		s.references[reference] = scopeRef{frame: frameID, scope: j}
+		scopes[j] = Scope{Name: name, VariablesReference: reference, Expensive: name == "Globals"}
+	}
+	return map[string]interface{}{"scopes": scopes}, nil
+}
+
+func (s *Server) variables(reference int) (interface{}, error) {
+	s.mu.Lock()
+	ref, ok := s.references[reference]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown variables reference %d", reference)
+	}
+	_, frame, err := s.frame(ref.frame)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := frame.Scopes()[ref.scope]
+	variables := []Variable{}
+	for _, name := range interpreter.Names(scope) {
+		variables = append(variables, Variable{Name: name, Value: interpreter.Stringify(scope[name]), Type: typeName(scope[name])})
+	}
+	return map[string]interface{}{"variables": variables}, nil
+}
+
+func (s *Server) evaluate(args evaluateArguments) (interface{}, error) {
+	if args.FrameID == 0 {
+		args.FrameID = 1
+	}
+	stop, frame, err := s.frame(args.FrameID)
+	if err != nil {
+		return nil, err
+	}
+	thisScanner := scanner.NewScanner(args.Expression)
+	thisScanner.ErrorOutput = io.Discard
+	tokens := thisScanner.ScanTokens()
+	if len(thisScanner.Errors) > 0 {
+		return nil, errors.New(thisScanner.Errors[0].Message)
+	}
+	thisParser := parser.NewParser(tokens)
+	thisParser.ErrorOutput = io.Discard
+	expr, err := thisParser.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := stop.Interpreter.EvaluateIn(frame, expr)
+	var runtimeErr *interpreter.RuntimeError
+	if errors.As(err, &runtimeErr) {
+		return nil, errors.New(runtimeErr.Message)
+	} else if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"result": interpreter.Stringify(value), "type": typeName(value), "variablesReference": 0}, nil
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case interpreter.LoxCallable:
+		return "function"
+	}
+	return fmt.Sprintf("%T", value)
+}
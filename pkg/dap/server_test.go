@@ -0,0 +1,323 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reilandeubank/golox/pkg/transport"
+)
+
+// client drives a server over pipes the way an editor would
+type client struct {
+	t       *testing.T
+	out     *transport.Writer
+	seq     int
+	events  chan message
+	replies chan message
+	output  strings.Builder
+	done    chan error
+}
+
+type message struct {
+	Type       string          `json:"type"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Event      string          `json:"event"`
+	Body       json.RawMessage `json:"body"`
+}
+
+func newClient(t *testing.T) *client {
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	c := &client{
+		t:       t,
+		out:     transport.NewWriter(clientOut),
+		events:  make(chan message, 100),
+		replies: make(chan message, 100),
+		done:    make(chan error, 1),
+	}
+	go func() {
+		err := NewServer(serverIn, serverOut).Run()
+		serverOut.Close()
+		c.done <- err
+	}()
+	go func() {
+		in := bufio.NewReader(clientIn)
+		for {
+			body, err := transport.ReadMessage(in)
+			if err != nil {
+				close(c.events)
+				return
+			}
+			var m message
+			json.Unmarshal(body, &m)
+			if m.Type == "response" {
+				c.replies <- m
+			} else {
+				c.events <- m
+			}
+		}
+	}()
+	return c
+}
+
+// request sends a command and decodes the body of its successful response into body
+func (c *client) request(command string, arguments interface{}, body interface{}) {
+	c.t.Helper()
+	if m := c.try(command, arguments); !m.Success {
+		c.t.Fatalf("%s failed: %s", command, m.Message)
+	} else if body != nil {
+		if err := json.Unmarshal(m.Body, body); err != nil {
+			c.t.Fatalf("decoding %s response: %v", command, err)
+		}
+	}
+}
+
+// try sends a command and returns its response, successful or not
+func (c *client) try(command string, arguments interface{}) message {
+	c.t.Helper()
+	c.seq++
+	body, _ := json.Marshal(map[string]interface{}{"seq": c.seq, "type": "request", "command": command, "arguments": arguments})
+	if err := c.out.WriteMessage(body); err != nil {
+		c.t.Fatal(err)
+	}
+	select {
+	case m := <-c.replies:
+		if m.RequestSeq != c.seq {
+			c.t.Fatalf("response to %d, want %d", m.RequestSeq, c.seq)
+		}
+		return m
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("no response to %s", command)
+	}
+	return message{}
+}
+
+// waitFor returns the body of the next event called name, collecting program output
+// along the way
+func (c *client) waitFor(name string) map[string]interface{} {
+	c.t.Helper()
+	for {
+		select {
+		case m, ok := <-c.events:
+			if !ok {
+				c.t.Fatalf("server closed before %s event", name)
+			}
+			var body map[string]interface{}
+			json.Unmarshal(m.Body, &body)
+			if m.Event == "output" {
+				c.output.WriteString(body["output"].(string))
+			}
+			if m.Event == name {
+				return body
+			}
+		case <-time.After(5 * time.Second):
+			c.t.Fatalf("no %s event", name)
+		}
+	}
+}
+
+const program = `var total = 0;
+fun add(a, b) {
+    var sum = a + b;
+    return sum;
+}
+for (var i = 0; i < 3; i = i + 1) {
+    total = add(total, i);
+}
+print total;
+`
+
+// launch starts a debug session of program with breakpoints on lines
+func launch(t *testing.T, stopOnEntry bool, lines ...int) *client {
+	path := filepath.Join(t.TempDir(), "program.lox")
+	if err := os.WriteFile(path, []byte(program), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := newClient(t)
+	c.request("initialize", map[string]string{"adapterID": "golox"}, nil)
+	c.request("launch", map[string]interface{}{"program": path, "stopOnEntry": stopOnEntry}, nil)
+	c.waitFor("initialized")
+
+	breakpoints := make([]map[string]int, len(lines))
+	for j, line := range lines {
+		breakpoints[j] = map[string]int{"line": line}
+	}
+	var result struct {
+		Breakpoints []Breakpoint `json:"breakpoints"`
+	}
+	c.request("setBreakpoints", map[string]interface{}{"source": map[string]string{"path": path}, "breakpoints": breakpoints}, &result)
+	for _, breakpoint := range result.Breakpoints {
+		if !breakpoint.Verified {
+			t.Fatalf("breakpoint on line %d not verified", breakpoint.Line)
+		}
+	}
+	c.request("configurationDone", nil, nil)
+	return c
+}
+
+func (c *client) stackTrace() []StackFrame {
+	var result struct {
+		StackFrames []StackFrame `json:"stackFrames"`
+	}
+	c.request("stackTrace", map[string]int{"threadId": threadID}, &result)
+	return result.StackFrames
+}
+
+// variables returns the variables of each scope of frame, keyed by scope name
+func (c *client) variables(frame int) map[string]map[string]string {
+	var scopes struct {
+		Scopes []Scope `json:"scopes"`
+	}
+	c.request("scopes", map[string]int{"frameId": frame}, &scopes)
+	all := make(map[string]map[string]string)
+	for _, scope := range scopes.Scopes {
+		var result struct {
+			Variables []Variable `json:"variables"`
+		}
+		c.request("variables", map[string]int{"variablesReference": scope.VariablesReference}, &result)
+		all[scope.Name] = make(map[string]string)
+		for _, variable := range result.Variables {
+			all[scope.Name][variable.Name] = variable.Value
+		}
+	}
+	return all
+}
+
+func (c *client) finish() {
+	c.waitFor("terminated")
+	c.request("disconnect", nil, nil)
+	if err := <-c.done; err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func TestBreakpointsAndScopes(t *testing.T) {
+	c := launch(t, false, 3)
+
+	for iteration := 0; iteration < 3; iteration++ {
+		if stopped := c.waitFor("stopped"); stopped["reason"] != "breakpoint" {
+			t.Fatalf("stopped for %v, want breakpoint", stopped["reason"])
+		}
+		frames := c.stackTrace()
+		if len(frames) != 2 || frames[0].Name != "add" || frames[0].Line != 3 || frames[1].Name != "<script>" || frames[1].Line != 7 {
+			t.Fatalf("unexpected stack %+v", frames)
+		}
+
+		scopes := c.variables(1)
+		if scopes["Locals"]["b"] != string(rune('0'+iteration)) {
+			t.Errorf("iteration %d: b is %q", iteration, scopes["Locals"]["b"])
+		}
+		if _, ok := scopes["Globals"]["add"]; !ok {
+			t.Errorf("globals %v do not include add", scopes["Globals"])
+		}
+		// The loop variable is in the block the for loop desugars to, outside its body
+		caller := c.variables(2)
+		found := false
+		for name, scope := range caller {
+			if name != "Globals" && scope["i"] == string(rune('0'+iteration)) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("the loop variable is missing from the caller's scopes %v", caller)
+		}
+		c.request("continue", map[string]int{"threadId": threadID}, nil)
+	}
+
+	exited := c.waitFor("exited")
+	if exited["exitCode"] != 0.0 {
+		t.Errorf("exit code %v", exited["exitCode"])
+	}
+	if c.output.String() != "3\n" {
+		t.Errorf("program printed %q", c.output.String())
+	}
+	c.finish()
+}
+
+func TestStepping(t *testing.T) {
+	c := launch(t, true)
+	if stopped := c.waitFor("stopped"); stopped["reason"] != "entry" {
+		t.Fatalf("stopped for %v, want entry", stopped["reason"])
+	}
+
+	steps := []struct {
+		command string
+		line    int
+		depth   int
+	}{
+		{"next", 2, 1},
+		{"next", 6, 1},
+		{"next", 7, 1},
+		{"stepIn", 3, 2},
+		{"next", 4, 2},
+		{"stepOut", 6, 1},
+		{"next", 7, 1},
+		{"next", 6, 1},
+	}
+	for _, step := range steps {
+		c.request(step.command, map[string]int{"threadId": threadID}, nil)
+		c.waitFor("stopped")
+		frames := c.stackTrace()
+		if frames[0].Line != step.line || len(frames) != step.depth {
+			t.Fatalf("after %s stopped at line %d with %d frames, want line %d with %d", step.command, frames[0].Line, len(frames), step.line, step.depth)
+		}
+	}
+	c.request("continue", map[string]int{"threadId": threadID}, nil)
+	c.finish()
+}
+
+func TestEvaluate(t *testing.T) {
+	c := launch(t, false, 4)
+	c.waitFor("stopped")
+
+	var result struct {
+		Result string `json:"result"`
+		Type   string `json:"type"`
+	}
+	c.request("evaluate", map[string]interface{}{"expression": "sum * 10", "frameId": 1}, &result)
+	if result.Result != "0" || result.Type != "number" {
+		t.Errorf("sum * 10 is %+v", result)
+	}
+	c.request("evaluate", map[string]interface{}{"expression": "total", "frameId": 2}, &result)
+	if result.Result != "0" {
+		t.Errorf("total is %+v", result)
+	}
+	if m := c.try("evaluate", map[string]interface{}{"expression": "missing", "frameId": 1}); m.Success || !strings.Contains(m.Message, "Undefined variable") {
+		t.Errorf("evaluating an undefined variable gave %+v", m)
+	}
+
+	// Disconnecting while paused ends the program
+	c.request("disconnect", nil, nil)
+	if err := <-c.done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInvalidBreakpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "program.lox")
+	os.WriteFile(path, []byte(program), 0o644)
+	c := newClient(t)
+	c.request("initialize", nil, nil)
+	c.request("launch", map[string]interface{}{"program": path}, nil)
+
+	var result struct {
+		Breakpoints []Breakpoint `json:"breakpoints"`
+	}
+	c.request("setBreakpoints", map[string]interface{}{"source": map[string]string{"path": path}, "breakpoints": []map[string]int{{"line": 5}}}, &result)
+	if len(result.Breakpoints) != 1 || result.Breakpoints[0].Verified {
+		t.Errorf("breakpoint on a closing brace is %+v", result.Breakpoints)
+	}
+	if m := c.try("continue", map[string]int{"threadId": threadID}); m.Success {
+		t.Error("continue succeeded before the program started")
+	}
+	c.request("disconnect", nil, nil)
+	<-c.done
+}
@@ -0,0 +1,202 @@
+// Package debug pauses a running Lox program at breakpoints and after steps. It is the
+// engine behind both golox debug and the Debug Adapter Protocol server; each supplies
+// its own Pause function to talk to the user.
+package debug
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/reilandeubank/golox/pkg/interpreter"
+	"github.com/reilandeubank/golox/pkg/parser"
+)
+
+// Action says how to resume after a pause
+type Action int
+
+const (
+	Continue Action = iota // run until the next breakpoint
+	Step                   // stop at the next line, entering calls
+	Next                   // stop at the next line in this function or its callers
+	Finish                 // stop once the current function returns
+)
+
+// Reasons a program stops, as reported in Stop.Reason
+const (
+	ReasonEntry      = "entry"
+	ReasonBreakpoint = "breakpoint"
+	ReasonStep       = "step"
+	ReasonPause      = "pause"
+)
+
+// Stop describes a paused program
+type Stop struct {
+	Interpreter *interpreter.Interpreter
+	Stmt        parser.Stmt // the statement about to run
+	Line        int
+	Reason      string
+}
+
+// Controller is an interpreter.Hook that decides where to pause. Breakpoints and steps
+// only stop at the first statement of a line each time execution reaches it, whether from
+// another line, a call returning or a loop going around again
+type Controller struct {
+	// Pause is called on the interpreter's goroutine whenever the program stops and
+	// blocks until it should resume. Returning an error such as interpreter.ErrHalted
+	// ends the program
+	Pause func(stop Stop) (Action, error)
+
+	mu          sync.Mutex
+	breakpoints map[int]bool
+	interrupted bool
+
+	action    Action
+	depth     int // the call depth when action was chosen
+	lastLine  int
+	lastDepth int
+	started   bool
+}
+
+// NewController returns a controller that stops at the first statement if stopOnEntry
+// is set and otherwise runs to the first breakpoint
+func NewController(stopOnEntry bool, pause func(stop Stop) (Action, error)) *Controller {
+	c := &Controller{Pause: pause, breakpoints: make(map[int]bool)}
+	if stopOnEntry {
+		c.action = Step
+	}
+	return c
+}
+
+// SetBreakpoint adds or removes a breakpoint. It may be called while the program runs
+func (c *Controller) SetBreakpoint(line int, enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if enabled {
+		c.breakpoints[line] = true
+	} else {
+		delete(c.breakpoints, line)
+	}
+}
+
+// ClearBreakpoints removes every breakpoint
+func (c *Controller) ClearBreakpoints() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.breakpoints = make(map[int]bool)
+}
+
+// Breakpoints returns the lines with breakpoints in order
+func (c *Controller) Breakpoints() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lines := make([]int, 0, len(c.breakpoints))
+	for line := range c.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// Interrupt asks the program to stop at the next statement. It may be called from any
+// goroutine
+func (c *Controller) Interrupt() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interrupted = true
+}
+
+// check reports whether there is a breakpoint on line and whether an interrupt was
+// requested, clearing the request
+func (c *Controller) check(line int) (breakpoint bool, interrupted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	interrupted, c.interrupted = c.interrupted, false
+	return c.breakpoints[line], interrupted
+}
+
+func (c *Controller) BeforeStmt(i *interpreter.Interpreter, stmt parser.Stmt) error {
+	// Blocks only group statements; stop at what they contain instead
+	if _, ok := stmt.(parser.BlockStmt); ok {
+		return nil
+	}
+	line := parser.StmtLine(stmt)
+	depth := i.Depth()
+	if line == 0 {
+		return nil
+	}
+	breakpoint, interrupted := c.check(line)
+	newLine := line != c.lastLine || depth != c.lastDepth
+	c.lastLine, c.lastDepth = line, depth
+
+	reason := ""
+	switch {
+	case interrupted:
+		reason = ReasonPause
+	case !newLine:
+		return nil
+	case breakpoint:
+		reason = ReasonBreakpoint
+	case c.action == Step, c.action == Next && depth <= c.depth, c.action == Finish && depth < c.depth:
+		reason = ReasonStep
+	default:
+		return nil
+	}
+	if !c.started {
+		c.started = true
+		if reason == ReasonStep {
+			reason = ReasonEntry
+		}
+	}
+
+	action, err := c.Pause(Stop{Interpreter: i, Stmt: stmt, Line: line, Reason: reason})
+	if err != nil {
+		return err
+	}
+	c.action, c.depth = action, depth
+	return nil
+}
+
+func (c *Controller) EnterCall(i *interpreter.Interpreter, function interpreter.LoxFunction) {}
+
+func (c *Controller) ExitCall(i *interpreter.Interpreter, function interpreter.LoxFunction) {
+	// Returning to the caller's line counts as reaching a new line
+	c.lastLine = 0
+}
+
+func (c *Controller) NextIteration(i *interpreter.Interpreter, stmt parser.WhileStmt) {
+	// So does going around a loop, even one written on a single line
+	c.lastLine = 0
+}
+
+// Lines returns the lines of statements execution can stop at, for checking breakpoints
+func Lines(statements []parser.Stmt) map[int]bool {
+	lines := make(map[int]bool)
+	var walk func(stmt parser.Stmt)
+	walk = func(stmt parser.Stmt) {
+		switch s := stmt.(type) {
+		case parser.BlockStmt:
+			for _, inner := range s.Statements {
+				walk(inner)
+			}
+			return
+		case parser.IfStmt:
+			walk(s.ThenBranch)
+			if s.ElseBranch != nil {
+				walk(s.ElseBranch)
+			}
+		case parser.WhileStmt:
+			walk(s.Body)
+		case parser.FunctionStmt:
+			for _, inner := range s.Body {
+				walk(inner)
+			}
+		}
+		if line := parser.StmtLine(stmt); line != 0 {
+			lines[line] = true
+		}
+	}
+	for _, stmt := range statements {
+		walk(stmt)
+	}
+	return lines
+}
@@ -0,0 +1,103 @@
+package debug
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/reilandeubank/golox/pkg/interpreter"
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+const loop = `var i = 0;
+while (i < 3) {
+    i = i + 1;
+}
+print i;
+`
+
+const call = `fun add(a, b) {
+    var sum = a + b;
+    return sum;
+}
+var x = add(1, 2);
+print x;
+`
+
+func parse(t *testing.T, source string) []parser.Stmt {
+	t.Helper()
+	thisScanner := scanner.NewScanner(source)
+	thisParser := parser.NewParser(thisScanner.ScanTokens())
+	statements, err := thisParser.Parse()
+	if err != nil || len(thisScanner.Errors) > 0 || len(thisParser.Errors) > 0 {
+		t.Fatalf("%q doesn't parse", source)
+	}
+	return statements
+}
+
+// run debugs source, resuming from every stop with action, and returns where it stopped
+// as "line reason"
+func run(t *testing.T, source string, stopOnEntry bool, breakpoints []int, actions ...Action) []string {
+	t.Helper()
+	statements := parse(t, source)
+
+	var stops []string
+	controller := NewController(stopOnEntry, func(stop Stop) (Action, error) {
+		stops = append(stops, fmt.Sprintf("%d %s", stop.Line, stop.Reason))
+		if len(stops) > 20 {
+			return Continue, interpreter.ErrHalted
+		}
+		if len(stops) <= len(actions) {
+			return actions[len(stops)-1], nil
+		}
+		return actions[len(actions)-1], nil
+	})
+	for _, line := range breakpoints {
+		controller.SetBreakpoint(line, true)
+	}
+	i := interpreter.NewInterpreter(interpreter.WithHook(controller), interpreter.WithStdout(io.Discard))
+	if err := i.Interpret(statements); err != nil {
+		t.Fatal(err)
+	}
+	return stops
+}
+
+func TestController(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		stopOnEntry bool
+		breakpoints []int
+		actions     []Action
+		want        []string
+	}{
+		{"breakpoint in loop", loop, false, []int{3}, []Action{Continue}, []string{"3 breakpoint", "3 breakpoint", "3 breakpoint"}},
+		{"breakpoint on loop", loop, false, []int{2}, []Action{Continue}, []string{"2 breakpoint"}},
+		{"step through loop", loop, true, nil, []Action{Step}, []string{"1 entry", "2 step", "3 step", "3 step", "3 step", "5 step"}},
+		{"next through loop", loop, false, []int{3}, []Action{Next}, []string{"3 breakpoint", "3 breakpoint", "3 breakpoint", "5 step"}},
+		{"one-line loop", "var i = 0;\nwhile (i < 3) i = i + 1;\n", false, []int{2}, []Action{Continue}, []string{"2 breakpoint", "2 breakpoint", "2 breakpoint"}},
+		{"one stop per line", "var a = 1; var b = 2;\nprint a + b;\n", true, nil, []Action{Step}, []string{"1 entry", "2 step"}},
+		{"step into call", call, true, nil, []Action{Step}, []string{"1 entry", "5 step", "2 step", "3 step", "6 step"}},
+		{"next over call", call, true, nil, []Action{Next}, []string{"1 entry", "5 step", "6 step"}},
+		{"finish call", call, false, []int{2}, []Action{Finish}, []string{"2 breakpoint", "6 step"}},
+		{"continue", call, true, nil, []Action{Continue}, []string{"1 entry"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := run(t, test.source, test.stopOnEntry, test.breakpoints, test.actions...)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("stopped at %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLines(t *testing.T) {
+	got := Lines(parse(t, call))
+	want := map[int]bool{1: true, 2: true, 3: true, 5: true, 6: true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got lines %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,215 @@
+// Package format reprints Lox source in a canonical layout: four-space indentation, one
+// statement per line, braces on the line that opens them, single spaces around binary
+// operators, and runs of blank lines collapsed to one. Comments are kept.
+package format
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+const indentUnit = "    "
+
+// ErrSyntax is returned for source that doesn't scan or parse. The errors themselves
+// are reported to os.Stderr like any other parse error
+var ErrSyntax = errors.New("source has syntax errors")
+
+// Source returns source in canonical form
+func Source(source string) (string, error) {
+	before, err := parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	commented := scanner.NewScannerWithComments(source)
+	formatted := layout(commented.ScanTokens())
+
+	// Layout must never change meaning, so check the result parses to the same tree
+	after, err := parse(formatted)
+	if err != nil || after != before {
+		return "", errors.New("formatting changed the program; this is a bug in the formatter")
+	}
+	return formatted, nil
+}
+
+// parse returns source's syntax tree rendered by parser.ASTPrinter
+func parse(source string) (string, error) {
+	thisScanner := scanner.NewScanner(source)
+	thisParser := parser.NewParser(thisScanner.ScanTokens())
+	statements, err := thisParser.Parse()
+	if err != nil || len(thisScanner.Errors) > 0 || len(thisParser.Errors) > 0 {
+		return "", ErrSyntax
+	}
+
+	var tree strings.Builder
+	for _, stmt := range statements {
+		tree.WriteString(parser.ASTPrinter{}.PrintStmt(stmt))
+		tree.WriteString("\n")
+	}
+	return tree.String(), nil
+}
+
+type printer struct {
+	out         strings.Builder
+	indent      int
+	parens      int // depth of open parentheses, inside which ';' doesn't end a line
+	atLineStart bool
+	blockStart  bool          // nothing has been written since the last '{'
+	commented   bool          // the current line ends in a comment
+	prev        scanner.Token // last token written, other than comments
+	prevUnary   bool          // prev is a prefix '-' or '!'
+	lastLine    int           // source line of the last token written
+}
+
+// layout writes tokens out in canonical form
+func layout(tokens []scanner.Token) string {
+	p := &printer{atLineStart: true}
+	for _, token := range tokens {
+		if token.Type == scanner.EOF {
+			break
+		}
+		p.token(token)
+	}
+	p.newline()
+	return strings.TrimLeft(p.out.String(), "\n")
+}
+
+func (p *printer) token(t scanner.Token) {
+	firstLine := t.Line - strings.Count(t.Lexeme, "\n")
+
+	if t.Type == scanner.COMMENT {
+		if p.out.Len() > 0 && firstLine == p.lastLine {
+			// A trailing comment stays on the line it followed
+			p.unwriteNewline()
+			p.out.WriteString(" " + strings.TrimRight(t.Lexeme, " \t\r"))
+		} else {
+			// A comment on a line of its own gets one in the output too, even in the
+			// middle of a statement
+			p.newline()
+			p.startLine(t, firstLine)
+			p.out.WriteString(strings.TrimRight(t.Lexeme, " \t\r"))
+		}
+		p.lastLine = t.Line
+		p.commented = true
+		p.newline()
+		return
+	}
+
+	switch t.Type {
+	case scanner.RIGHT_BRACE:
+		p.indent--
+		p.newline()
+	case scanner.ELSE:
+		if p.prev.Type == scanner.RIGHT_BRACE && p.atLineStart && !p.commented {
+			// Join "} else" onto one line
+			p.unwriteNewline()
+		} else if p.prev.Type == scanner.SEMICOLON && p.atLineStart && t.Line == p.lastLine {
+			// Keep "if (a) print 1; else print 2;" on one line when it was written that way
+			p.unwriteNewline()
+		}
+	}
+
+	if p.atLineStart {
+		p.startLine(t, firstLine)
+	} else if p.spaceBefore(t) {
+		p.out.WriteString(" ")
+	}
+	p.out.WriteString(t.Lexeme)
+	p.prevUnary = (t.Type == scanner.MINUS || t.Type == scanner.BANG) && !endsOperand(p.prev)
+	p.prev = t
+	p.lastLine = t.Line
+
+	switch t.Type {
+	case scanner.LEFT_PAREN:
+		p.parens++
+	case scanner.RIGHT_PAREN:
+		p.parens--
+	case scanner.LEFT_BRACE:
+		p.indent++
+		p.newline()
+		p.blockStart = true
+	case scanner.RIGHT_BRACE:
+		p.newline()
+	case scanner.SEMICOLON:
+		if p.parens == 0 {
+			p.newline()
+		}
+	}
+}
+
+// startLine indents a new line for t, first keeping one blank line if the source had any
+// since the last token. Blank lines at the start or end of a block are dropped
+func (p *printer) startLine(t scanner.Token, line int) {
+	if line-p.lastLine > 1 && p.out.Len() > 0 && !p.blockStart && t.Type != scanner.RIGHT_BRACE {
+		p.out.WriteString("\n")
+	}
+	indent := p.indent
+	if p.continues(t) {
+		indent++
+	}
+	p.out.WriteString(strings.Repeat(indentUnit, max(indent, 0)))
+	p.atLineStart = false
+	p.blockStart = false
+	p.commented = false
+}
+
+// continues reports whether a line starting with t carries on a statement that a comment
+// broke, which is indented one level further
+func (p *printer) continues(t scanner.Token) bool {
+	if p.prev.Lexeme == "" || t.Type == scanner.RIGHT_BRACE {
+		return false
+	}
+	switch p.prev.Type {
+	case scanner.SEMICOLON:
+		return p.parens > 0
+	case scanner.LEFT_BRACE, scanner.RIGHT_BRACE:
+		return false
+	}
+	return true
+}
+
+func (p *printer) newline() {
+	if !p.atLineStart {
+		p.out.WriteString("\n")
+		p.atLineStart = true
+	}
+}
+
+func (p *printer) unwriteNewline() {
+	str := strings.TrimSuffix(p.out.String(), "\n")
+	p.out.Reset()
+	p.out.WriteString(str)
+	p.atLineStart = false
+}
+
+// spaceBefore reports whether t is separated from the previous token on the same line
+func (p *printer) spaceBefore(t scanner.Token) bool {
+	switch t.Type {
+	case scanner.RIGHT_PAREN, scanner.RIGHT_BRACKET, scanner.COMMA, scanner.SEMICOLON, scanner.DOT:
+		return false
+	case scanner.LEFT_PAREN, scanner.LEFT_BRACKET:
+		// Calls and indexes hug their operand: f(x), f(x)(y), xs[0], fun name(params)
+		if endsOperand(p.prev) {
+			return false
+		}
+	}
+	switch p.prev.Type {
+	case scanner.LEFT_PAREN, scanner.LEFT_BRACKET, scanner.DOT, scanner.ELLIPSIS:
+		return false
+	case scanner.SEMICOLON:
+		return true
+	}
+	return !p.prevUnary
+}
+
+// endsOperand reports whether t can end an operand, making a following '-' binary
+func endsOperand(t scanner.Token) bool {
+	switch t.Type {
+	case scanner.IDENTIFIER, scanner.NUMBER, scanner.STRING, scanner.TRUE, scanner.FALSE, scanner.NIL, scanner.RIGHT_PAREN, scanner.RIGHT_BRACKET:
+		return true
+	}
+	return false
+}
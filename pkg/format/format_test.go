@@ -0,0 +1,102 @@
+package format
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"spacing", "var  x=1+2*-3;print(x);", "var x = 1 + 2 * -3;\nprint (x);\n"},
+		{"one statement per line", "var a = 1; var b = 2;", "var a = 1;\nvar b = 2;\n"},
+		{"blocks", "fun f(a,b){if(a){return b;}else{return a;}}", "fun f(a, b) {\n    if (a) {\n        return b;\n    } else {\n        return a;\n    }\n}\n"},
+		{"calls and indexes", "print f (1) [0] ( 2 );", "print f(1)[0](2);\n"},
+		{"rest parameters", "fun f(a, ... rest) {}", "fun f(a, ...rest) {\n}\n"},
+		{"for clauses", "for(var i=0;i<3;i=i+1) print i;", "for (var i = 0; i < 3; i = i + 1) print i;\n"},
+		{"else on one line", "if (a) print 1; else print 2;", "if (a) print 1; else print 2;\n"},
+		{"else on its own line", "if (a) print 1;\nelse print 2;", "if (a) print 1;\nelse print 2;\n"},
+		{"trailing comment", "var x = 1;   // one\nprint x; // two", "var x = 1; // one\nprint x; // two\n"},
+		{"comment lines", "// top\n{\n// inside\nprint 1;\n}", "// top\n{\n    // inside\n    print 1;\n}\n"},
+		{"comment inside a statement", "var x = 1 + // c\n2;", "var x = 1 + // c\n    2;\n"},
+		{"comment inside a nested statement", "{\nvar x = 1 + // c\n2;\n}", "{\n    var x = 1 + // c\n        2;\n}\n"},
+		{"comment line inside a statement", "var x = 1 +\n  // explain\n  2;", "var x = 1 +\n    // explain\n    2;\n"},
+		{"comment inside for clauses", "for (var i = 0; // c\ni < 1; i = i + 1) print i;", "for (var i = 0; // c\n    i < 1; i = i + 1) print i;\n"},
+		{"blank lines collapse", "print 1;\n\n\n\nprint 2;", "print 1;\n\nprint 2;\n"},
+		{"blank lines at block edges", "{\n\nprint 1;\n\n}", "{\n    print 1;\n}\n"},
+		{"leading blank lines", "\n\nprint 1;", "print 1;\n"},
+		{"unary and binary minus", "print - 1 - -x;", "print -1 - -x;\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Source(test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+			if again, err := Source(got); err != nil || again != got {
+				t.Errorf("formatting again gave:\n%s\n(%v)", again, err)
+			}
+		})
+	}
+}
+
+func TestSourceSyntaxError(t *testing.T) {
+	if _, err := Source("print ;"); err != ErrSyntax {
+		t.Errorf("got %v, want ErrSyntax", err)
+	}
+}
+
+// parses reports whether source scans and parses without errors
+func parses(source string) bool {
+	thisScanner := scanner.NewScanner(source)
+	thisScanner.ErrorOutput = io.Discard
+	thisParser := parser.NewParser(thisScanner.ScanTokens())
+	thisParser.ErrorOutput = io.Discard
+	_, err := thisParser.Parse()
+	return err == nil && len(thisScanner.Errors) == 0 && len(thisParser.Errors) == 0
+}
+
+// TestIdempotent checks that formatting every program in the repository that parses
+// gives source that formatting leaves alone
+func TestIdempotent(t *testing.T) {
+	for _, dir := range []string{"../../test", "../../bench", "../../testing"} {
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() || filepath.Ext(path) != ".lox" {
+				return err
+			}
+			source, err := os.ReadFile(path)
+			if err != nil || !parses(string(source)) {
+				return err
+			}
+			t.Run(filepath.ToSlash(path), func(t *testing.T) {
+				once, err := Source(string(source))
+				if err != nil {
+					t.Fatal(err)
+				}
+				twice, err := Source(once)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if twice != once {
+					t.Errorf("formatting again changed\n%s\nto\n%s", once, twice)
+				}
+			})
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package interpreter
+
+import (
+	"io"
+	"testing"
+
+	"github.com/reilandeubank/golox/internal/corpus"
+)
+
+// BenchmarkInterpret measures running each workload in a fresh interpreter, leaving out
+// scanning and parsing
+func BenchmarkInterpret(b *testing.B) {
+	for _, w := range corpus.Benchmarks(b) {
+		statements := parse(b, w.Source)
+		b.Run(w.Name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				i := NewInterpreter(WithStdout(io.Discard))
+				if err := i.Interpret(statements); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBudgetExceeded is matched (via errors.Is) by every error returned when execution is
+// stopped early by a step limit, timeout or cancelled context
+var ErrBudgetExceeded = errors.New("execution budget exceeded")
+
+// BudgetError reports where execution stopped and why
+type BudgetError struct {
+	Line   int
+	Reason string
+	cause  error
+}
+
+func (b *BudgetError) Error() string {
+	return fmt.Sprintf("[line %d] Execution stopped: %s", b.Line, b.Reason)
+}
+
+// Unwrap lets errors.Is match both ErrBudgetExceeded and, for cancellations,
+// the context error that caused them
+func (b *BudgetError) Unwrap() []error {
+	if b.cause != nil {
+		return []error{ErrBudgetExceeded, b.cause}
+	}
+	return []error{ErrBudgetExceeded}
+}
+
+// WithStepLimit caps the number of statements executed plus functions called by each
+// call to Interpret. A limit of 0 means no limit
+func WithStepLimit(steps int) Option {
+	return func(i *Interpreter) {
+		i.maxSteps = steps
+	}
+}
+
+// WithTimeout bounds the wall-clock time of each call to Interpret
+func WithTimeout(d time.Duration) Option {
+	return func(i *Interpreter) {
+		i.timeout = d
+	}
+}
+
+// tick charges one step against the budget, blaming line if it is exhausted. A line of 0
+// (a node without a source position) falls back to the last line that had one
+func (i *Interpreter) tick(line int) error {
+	if line != 0 {
+		i.line = line
+	}
+	i.steps++
+	if i.maxSteps > 0 && i.steps > i.maxSteps {
+		return &BudgetError{Line: i.line, Reason: fmt.Sprintf("step limit of %d exceeded", i.maxSteps)}
+	}
+	if i.ctx == nil {
+		return nil
+	}
+	select {
+	case <-i.ctx.Done():
+		return &BudgetError{Line: i.line, Reason: i.ctx.Err().Error(), cause: i.ctx.Err()}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,137 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+func parse(t testing.TB, source string) []parser.Stmt {
+	t.Helper()
+	thisScanner := scanner.NewScanner(source)
+	thisParser := parser.NewParser(thisScanner.ScanTokens())
+	statements, err := thisParser.Parse()
+	if err != nil || len(thisScanner.Errors) > 0 || len(thisParser.Errors) > 0 {
+		t.Fatalf("%q doesn't parse", source)
+	}
+	return statements
+}
+
+const infiniteLoop = `print "start";
+while (true) {
+  var x = 1;
+}`
+
+func TestBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		ctx    func() (context.Context, context.CancelFunc)
+		cause  error // the context error the BudgetError wraps, if any
+		reason string
+	}{
+		{
+			name:   "step limit",
+			opts:   []Option{WithStepLimit(100)},
+			reason: "step limit of 100 exceeded",
+		},
+		{
+			name:   "timeout",
+			opts:   []Option{WithTimeout(10 * time.Millisecond)},
+			cause:  context.DeadlineExceeded,
+			reason: "context deadline exceeded",
+		},
+		{
+			name: "cancelled context",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(10*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			cause:  context.Canceled,
+			reason: "context canceled",
+		},
+		{
+			name: "context deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			cause:  context.DeadlineExceeded,
+			reason: "context deadline exceeded",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.Background(), context.CancelFunc(func() {})
+			if test.ctx != nil {
+				ctx, cancel = test.ctx()
+			}
+			defer cancel()
+
+			var stdout, stderr bytes.Buffer
+			i := NewInterpreter(append(test.opts, WithStdout(&stdout), WithStderr(&stderr))...)
+			statements := parse(t, infiniteLoop)
+			done := make(chan error)
+			go func() {
+				done <- i.InterpretContext(ctx, statements)
+			}()
+			var err error
+			select {
+			case err = <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the loop wasn't stopped")
+			}
+
+			if !errors.Is(err, ErrBudgetExceeded) {
+				t.Fatalf("got %v, want ErrBudgetExceeded", err)
+			}
+			if test.cause != nil && !errors.Is(err, test.cause) {
+				t.Errorf("got %v, want it to match %v", err, test.cause)
+			}
+			var budgetErr *BudgetError
+			if !errors.As(err, &budgetErr) {
+				t.Fatalf("got %T, want *BudgetError", err)
+			}
+			if budgetErr.Line != 3 || budgetErr.Reason != test.reason {
+				t.Errorf("stopped on line %d because %q, want line 3 because %q", budgetErr.Line, budgetErr.Reason, test.reason)
+			}
+			if stdout.String() != "start\n" {
+				t.Errorf("printed %q before stopping, want %q", stdout.String(), "start\n")
+			}
+			if want := "[line 3] Execution stopped: " + test.reason + "\n"; stderr.String() != want {
+				t.Errorf("reported %q, want %q", stderr.String(), want)
+			}
+		})
+	}
+}
+
+func TestBudgetResetsEachRun(t *testing.T) {
+	i := NewInterpreter(WithStdout(io.Discard), WithStderr(io.Discard), WithStepLimit(50))
+	loop := parse(t, "for (var n = 0; n < 10; n = n + 1) {}")
+	for run := 0; run < 3; run++ {
+		if err := i.Interpret(loop); err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+	}
+}
+
+func TestCancelledBeforeStarting(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var stdout strings.Builder
+	i := NewInterpreter(WithStdout(&stdout), WithStderr(io.Discard))
+	err := i.InterpretContext(ctx, parse(t, "print 1;"))
+	if !errors.Is(err, ErrBudgetExceeded) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want a cancelled BudgetError", err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("printed %q after being cancelled", stdout.String())
+	}
+}
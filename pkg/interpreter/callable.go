@@ -1,21 +1,49 @@
 package interpreter
 
 import (
+	"math"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 	"fmt"
 	"github.com/reilandeubank/golox/pkg/parser"
 )
 
 type LoxCallable interface {
-	Arity() int
+	// Arity returns the fewest and most arguments the callable takes. A max of Variadic
+	// means there is no upper limit
+	Arity() (min, max int)
 	Call(i *Interpreter, arguments []interface{}) (interface{}, error)
 	String() string
 }
 
+// Variadic is the max Arity of a callable that takes any number of arguments beyond its min
+const Variadic = -1
+
+// Accepts reports whether function can be called with n arguments
+func Accepts(function LoxCallable, n int) bool {
+	min, max := function.Arity()
+	return n >= min && (max == Variadic || n <= max)
+}
+
+// ArityString describes how many arguments function takes, e.g. "2", "1 to 3" or
+// "at least 1"
+func ArityString(function LoxCallable) string {
+	min, max := function.Arity()
+	switch max {
+	case Variadic:
+		return "at least " + strconv.Itoa(min)
+	case min:
+		return strconv.Itoa(min)
+	}
+	return strconv.Itoa(min) + " to " + strconv.Itoa(max)
+}
+
 type clock struct{}
 
-func (c *clock) Arity() int {
-	return 0
+func (c *clock) Arity() (int, int) {
+	return 0, 0
 }
 
 func (c *clock) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
@@ -28,8 +56,8 @@ func (c clock) String() string {
 
 type toStr struct{}
 
-func (t *toStr) Arity() int {
-	return 1
+func (t *toStr) Arity() (int, int) {
+	return 1, 1
 }
 
 func (t *toStr) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
@@ -40,6 +68,437 @@ func (t toStr) String() string {
 	return "<native fn>"
 }
 
+// stringArgument returns an argument to the native name that must be a string, described
+// as what in the error if it isn't
+func stringArgument(name string, what string, argument interface{}) (string, error) {
+	value, ok := argument.(string)
+	if !ok {
+		return "", &RuntimeError{Message: name + ": " + what + " must be a string."}
+	}
+	return value, nil
+}
+
+// intArgument returns an argument to the native name that must be a whole number
+func intArgument(name string, what string, argument interface{}) (int, error) {
+	value, ok := argument.(float64)
+	if !ok || value != math.Trunc(value) || math.Abs(value) > math.MaxInt32 {
+		return 0, &RuntimeError{Message: name + ": " + what + " must be a whole number."}
+	}
+	return int(value), nil
+}
+
+// Strings are indexed by character (rune) rather than byte throughout
+
+type length struct{}
+
+func (l *length) Arity() (int, int) {
+	return 1, 1
+}
+
+// Call returns the number of characters in a string or elements in a list
+func (l *length) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	switch value := arguments[0].(type) {
+	case string:
+		return float64(utf8.RuneCountInString(value)), nil
+	case *LoxList:
+		return float64(len(value.Elements)), nil
+	}
+	return nil, &RuntimeError{Message: "len: argument must be a string or list."}
+}
+
+func (l length) String() string {
+	return "<native fn>"
+}
+
+type substr struct{}
+
+func (s *substr) Arity() (int, int) {
+	return 3, 3
+}
+
+// Call returns count characters of a string starting from the character at start
+func (s *substr) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	str, err := stringArgument("substr", "string", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	start, err := intArgument("substr", "start", arguments[1])
+	if err != nil {
+		return nil, err
+	}
+	count, err := intArgument("substr", "length", arguments[2])
+	if err != nil {
+		return nil, err
+	}
+	runes := []rune(str)
+	if start < 0 || count < 0 || start+count > len(runes) {
+		return nil, &RuntimeError{Message: fmt.Sprintf("substr: %d characters from %d is out of range for a string of length %d.", count, start, len(runes))}
+	}
+	return string(runes[start : start+count]), nil
+}
+
+func (s substr) String() string {
+	return "<native fn>"
+}
+
+type indexOf struct{}
+
+func (x *indexOf) Arity() (int, int) {
+	return 2, 2
+}
+
+// Call returns the position of the first occurrence of a substring, or -1 if there is none
+func (x *indexOf) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	str, err := stringArgument("indexOf", "string", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	sub, err := stringArgument("indexOf", "substring", arguments[1])
+	if err != nil {
+		return nil, err
+	}
+	index := strings.Index(str, sub)
+	if index < 0 {
+		return -1.0, nil
+	}
+	return float64(utf8.RuneCountInString(str[:index])), nil
+}
+
+func (x indexOf) String() string {
+	return "<native fn>"
+}
+
+type contains struct{}
+
+func (c *contains) Arity() (int, int) {
+	return 2, 2
+}
+
+func (c *contains) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	str, err := stringArgument("contains", "string", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	sub, err := stringArgument("contains", "substring", arguments[1])
+	if err != nil {
+		return nil, err
+	}
+	return strings.Contains(str, sub), nil
+}
+
+func (c contains) String() string {
+	return "<native fn>"
+}
+
+type startsWith struct{}
+
+func (s *startsWith) Arity() (int, int) {
+	return 2, 2
+}
+
+func (s *startsWith) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	str, err := stringArgument("startsWith", "string", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := stringArgument("startsWith", "prefix", arguments[1])
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasPrefix(str, prefix), nil
+}
+
+func (s startsWith) String() string {
+	return "<native fn>"
+}
+
+type endsWith struct{}
+
+func (e *endsWith) Arity() (int, int) {
+	return 2, 2
+}
+
+func (e *endsWith) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	str, err := stringArgument("endsWith", "string", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	suffix, err := stringArgument("endsWith", "suffix", arguments[1])
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasSuffix(str, suffix), nil
+}
+
+func (e endsWith) String() string {
+	return "<native fn>"
+}
+
+type split struct{}
+
+func (s *split) Arity() (int, int) {
+	return 2, 2
+}
+
+// Call returns a list of the parts of a string between each separator, or of its
+// characters if the separator is ""
+func (s *split) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	str, err := stringArgument("split", "string", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	sep, err := stringArgument("split", "separator", arguments[1])
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(str, sep)
+	elements := make([]interface{}, len(parts))
+	for j, part := range parts {
+		elements[j] = part
+	}
+	return NewLoxList(elements), nil
+}
+
+func (s split) String() string {
+	return "<native fn>"
+}
+
+type join struct{}
+
+func (j *join) Arity() (int, int) {
+	return 2, 2
+}
+
+// Call returns the elements of a list as they would be printed, separated by a string
+func (j *join) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	list, ok := arguments[0].(*LoxList)
+	if !ok {
+		return nil, &RuntimeError{Message: "join: first argument must be a list."}
+	}
+	sep, err := stringArgument("join", "separator", arguments[1])
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(list.Elements))
+	for k, element := range list.Elements {
+		parts[k] = Stringify(element)
+	}
+	return strings.Join(parts, sep), nil
+}
+
+func (j join) String() string {
+	return "<native fn>"
+}
+
+type upper struct{}
+
+func (u *upper) Arity() (int, int) {
+	return 1, 1
+}
+
+func (u *upper) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	str, err := stringArgument("upper", "argument", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	return strings.ToUpper(str), nil
+}
+
+func (u upper) String() string {
+	return "<native fn>"
+}
+
+type lower struct{}
+
+func (l *lower) Arity() (int, int) {
+	return 1, 1
+}
+
+func (l *lower) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	str, err := stringArgument("lower", "argument", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	return strings.ToLower(str), nil
+}
+
+func (l lower) String() string {
+	return "<native fn>"
+}
+
+type trim struct{}
+
+func (t *trim) Arity() (int, int) {
+	return 1, 1
+}
+
+// Call returns a string without leading and trailing white space
+func (t *trim) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	str, err := stringArgument("trim", "argument", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	return strings.TrimSpace(str), nil
+}
+
+func (t trim) String() string {
+	return "<native fn>"
+}
+
+type replace struct{}
+
+func (r *replace) Arity() (int, int) {
+	return 3, 3
+}
+
+// Call returns a string with every occurrence of old replaced by new
+func (r *replace) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	str, err := stringArgument("replace", "string", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	old, err := stringArgument("replace", "old", arguments[1])
+	if err != nil {
+		return nil, err
+	}
+	replacement, err := stringArgument("replace", "new", arguments[2])
+	if err != nil {
+		return nil, err
+	}
+	return strings.ReplaceAll(str, old, replacement), nil
+}
+
+func (r replace) String() string {
+	return "<native fn>"
+}
+
+// maxStringLength bounds the strings repeat builds, so a mistaken count is an error
+// rather than exhausting memory
+const maxStringLength = 1 << 26
+
+type repeat struct{}
+
+func (r *repeat) Arity() (int, int) {
+	return 2, 2
+}
+
+func (r *repeat) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	str, err := stringArgument("repeat", "string", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	count, err := intArgument("repeat", "count", arguments[1])
+	if err != nil {
+		return nil, err
+	}
+	if count < 0 {
+		return nil, &RuntimeError{Message: "repeat: count must not be negative."}
+	}
+	if len(str) > 0 && count > maxStringLength/len(str) {
+		return nil, &RuntimeError{Message: "repeat: result is too long."}
+	}
+	return strings.Repeat(str, count), nil
+}
+
+func (r repeat) String() string {
+	return "<native fn>"
+}
+
+type charAt struct{}
+
+func (c *charAt) Arity() (int, int) {
+	return 2, 2
+}
+
+func (c *charAt) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	str, err := stringArgument("charAt", "string", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	index, err := intArgument("charAt", "index", arguments[1])
+	if err != nil {
+		return nil, err
+	}
+	runes := []rune(str)
+	if index < 0 || index >= len(runes) {
+		return nil, &RuntimeError{Message: fmt.Sprintf("charAt: index %d is out of range for a string of length %d.", index, len(runes))}
+	}
+	return string(runes[index]), nil
+}
+
+func (c charAt) String() string {
+	return "<native fn>"
+}
+
+type ord struct{}
+
+func (o *ord) Arity() (int, int) {
+	return 1, 1
+}
+
+// Call returns the Unicode code point of a one-character string
+func (o *ord) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	str, err := stringArgument("ord", "argument", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	if utf8.RuneCountInString(str) != 1 {
+		return nil, &RuntimeError{Message: "ord: argument must be a single character."}
+	}
+	r, _ := utf8.DecodeRuneInString(str)
+	return float64(r), nil
+}
+
+func (o ord) String() string {
+	return "<native fn>"
+}
+
+type chr struct{}
+
+func (c *chr) Arity() (int, int) {
+	return 1, 1
+}
+
+// Call returns the one-character string for a Unicode code point
+func (c *chr) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	code, err := intArgument("chr", "argument", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	if code < 0 || code > utf8.MaxRune {
+		return nil, &RuntimeError{Message: fmt.Sprintf("chr: %d is not a Unicode code point.", code)}
+	}
+	return string(rune(code)), nil
+}
+
+func (c chr) String() string {
+	return "<native fn>"
+}
+
+type parseNumber struct{}
+
+func (p *parseNumber) Arity() (int, int) {
+	return 1, 1
+}
+
+// Call returns the number a string spells out, ignoring surrounding white space, or nil
+// if it isn't one
+func (p *parseNumber) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	str, err := stringArgument("parseNumber", "argument", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	if err != nil {
+		return nil, nil
+	}
+	return value, nil
+}
+
+func (p parseNumber) String() string {
+	return "<native fn>"
+}
+
 type LoxFunction struct {
 	Declaration parser.FunctionStmt
 	Closure     *environment
@@ -50,23 +509,43 @@ func (l LoxFunction) String() string {
 	return "<fn " + l.Declaration.Name.Lexeme + ">"
 }
 
-func (l LoxFunction) Arity() int {
-	return len(l.Declaration.Params)
+func (l LoxFunction) Arity() (int, int) {
+	n := len(l.Declaration.Params)
+	if l.Declaration.Rest {
+		return n - 1, Variadic
+	}
+	return n, n
 }
 
 func (l LoxFunction) Call(i *Interpreter, arguments []interface{}) (retVal interface{}, errVal error) {
 	env := NewEnvironmentWithEnclosing(*l.Closure)
 
 	for j, param := range l.Declaration.Params {
+		if l.Declaration.Rest && j == len(l.Declaration.Params)-1 {
+			rest := append([]interface{}{}, arguments[j:]...)
+			env.define(param.Lexeme, NewLoxList(rest))
+			break
+		}
 		env.define(param.Lexeme, arguments[j])
 	}
 
+	i.frames = append(i.frames, &Frame{Name: l.Declaration.Name.Lexeme, Line: l.Declaration.Name.Line, env: &env})
+	for _, hook := range i.hooks {
+		hook.EnterCall(i, l)
+	}
+	defer func() {
+		for _, hook := range i.hooks {
+			hook.ExitCall(i, l)
+		}
+		i.popFrame()
+	}()
+
 	defer func() {
 		if r := recover(); r != nil {
 			if returnErr, ok := r.(*ReturnError); ok {
                 retVal = returnErr.Value
             } else {
-                errVal = &RuntimeError{Message: "Return value error"}
+                panic(r)
             }
 		} 
 	}()
@@ -7,6 +7,7 @@ import (
 type environment struct {
 	enclosing *environment
 	values map[string]interface{}
+	constants map[string]bool // names in values that can't be assigned or redefined
 }
 
 func NewEnvironment() environment {
@@ -21,6 +22,23 @@ func (e *environment) define(name string, value interface{}) {
 	e.values[name] = value	// this allows for variable redefinition. May be weird in normal code, but is useful for REPL
 }
 
+// defineConstant binds a name that programs can read but not change
+func (e *environment) defineConstant(name string, value interface{}) {
+	e.define(name, value)
+	if e.constants == nil {
+		e.constants = make(map[string]bool)
+	}
+	e.constants[name] = true
+}
+
+// redefine checks that a declaration may bind name in e, which it can't if name is a constant
+func (e *environment) redefine(name scanner.Token) error {
+	if e.constants[name.Lexeme] {
+		return &RuntimeError{Token: name, Message: "Can't redefine constant '" + name.Lexeme + "'."}
+	}
+	return nil
+}
+
 func (e *environment) get(name scanner.Token) (interface{}, error) {
 	value, ok := e.values[name.Lexeme]
 	if !ok && e.enclosing != nil {
@@ -36,8 +54,21 @@ func (e *environment) assign(name scanner.Token, value interface{}) error {
 	if !ok && e.enclosing != nil {
 		return e.enclosing.assign(name, value)
 	} else if !ok {
-		return &RuntimeError{Token: name, Message: "Undefined variable '" + name.Lexeme}
+		return &RuntimeError{Token: name, Message: "Undefined variable '" + name.Lexeme + "'."}
+	} else if e.constants[name.Lexeme] {
+		return &RuntimeError{Token: name, Message: "Can't assign to constant '" + name.Lexeme + "'."}
 	}
 	e.values[name.Lexeme] = value
 	return nil
-}
\ No newline at end of file
+}
+
+// names returns every name visible from e, including those in enclosing environments
+func (e *environment) names() []string {
+	var names []string
+	for env := e; env != nil; env = env.enclosing {
+		for name := range env.values {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -6,16 +6,6 @@ import (
 	"github.com/reilandeubank/golox/pkg/scanner"
 )
 
-var hadErrorFlag bool = false
-
-func HadError() bool {
-	return hadErrorFlag
-}
-
-func SetErrorFlag(val bool) {
-	hadErrorFlag = val
-}
-
 type RuntimeError struct {
 	Token   scanner.Token
 	Message string
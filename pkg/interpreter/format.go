@@ -0,0 +1,160 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxFormatWidth bounds the width and precision a verb may ask for
+const maxFormatWidth = 1000
+
+// sprintf formats arguments for the native name according to format, which may use
+//
+//	%s  a string
+//	%d  a whole number
+//	%f  a number, with six digits after the point unless a precision is given
+//	%v  any value, as print shows it
+//	%%  a literal percent sign
+//
+// Between the % and the verb, a - aligns the value to the left, a number sets the width
+// to pad it to and a . followed by a number sets the precision of %f (or how many
+// characters of %s and %v to keep)
+func sprintf(name string, format string, arguments []interface{}) (string, error) {
+	fail := func(message string, args ...interface{}) (string, error) {
+		return "", &RuntimeError{Message: name + ": " + fmt.Sprintf(message, args...)}
+	}
+
+	var out strings.Builder
+	next := 0
+	for j := 0; j < len(format); j++ {
+		if format[j] != '%' {
+			out.WriteByte(format[j])
+			continue
+		}
+		start := j
+		j++
+		if j < len(format) && format[j] == '%' {
+			out.WriteByte('%')
+			continue
+		}
+
+		spec := "%"
+		if j < len(format) && format[j] == '-' {
+			spec += "-"
+			j++
+		}
+		number := func() (string, bool) {
+			digits := j
+			for j < len(format) && format[j] >= '0' && format[j] <= '9' {
+				j++
+			}
+			if digits == j {
+				return "", true
+			}
+			n, err := strconv.Atoi(format[digits:j])
+			return format[digits:j], err == nil && n <= maxFormatWidth
+		}
+		width, ok := number()
+		if !ok {
+			return fail("width %s is too large.", width)
+		}
+		spec += width
+		precision := ""
+		if j < len(format) && format[j] == '.' {
+			j++
+			precision, ok = number()
+			if !ok {
+				return fail("precision %s is too large.", precision)
+			}
+			precision = "." + precision
+		}
+		if j >= len(format) {
+			return fail("format string ends in the middle of %s.", format[start:])
+		}
+		verb := format[start : j+1]
+
+		if next >= len(arguments) {
+			return fail("no argument for %s.", verb)
+		}
+		argument := arguments[next]
+		next++
+
+		switch format[j] {
+		case 's':
+			s, ok := argument.(string)
+			if !ok {
+				return fail("%s needs a string but got %s.", verb, Stringify(argument))
+			}
+			out.WriteString(fmt.Sprintf(spec+precision+"s", s))
+		case 'v':
+			out.WriteString(fmt.Sprintf(spec+precision+"s", Stringify(argument)))
+		case 'd':
+			x, ok := argument.(float64)
+			if !ok || x != math.Trunc(x) || math.IsInf(x, 0) {
+				return fail("%s needs a whole number but got %s.", verb, Stringify(argument))
+			}
+			if precision != "" {
+				return fail("%s can't have a precision.", verb)
+			}
+			out.WriteString(fmt.Sprintf(spec+"s", strconv.FormatFloat(x, 'f', 0, 64)))
+		case 'f':
+			x, ok := argument.(float64)
+			if !ok {
+				return fail("%s needs a number but got %s.", verb, Stringify(argument))
+			}
+			out.WriteString(fmt.Sprintf(spec+precision+"f", x))
+		default:
+			return fail("unknown verb %s.", verb)
+		}
+	}
+	if next < len(arguments) {
+		return fail("%d arguments given but the format string uses %d.", len(arguments), next)
+	}
+	return out.String(), nil
+}
+
+type format struct{}
+
+func (f *format) Arity() (int, int) {
+	return 1, Variadic
+}
+
+// Call returns the arguments after the first formatted according to the first
+func (f *format) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	formatString, err := stringArgument("format", "first argument", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	return sprintf("format", formatString, arguments[1:])
+}
+
+func (f format) String() string {
+	return "<native fn>"
+}
+
+type printf struct{}
+
+func (p *printf) Arity() (int, int) {
+	return 1, Variadic
+}
+
+// Call prints the arguments after the first formatted according to the first, without
+// adding a newline
+func (p *printf) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	formatString, err := stringArgument("printf", "first argument", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	s, err := sprintf("printf", formatString, arguments[1:])
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprint(i.stdout, s)
+	return nil, nil
+}
+
+func (p printf) String() string {
+	return "<native fn>"
+}
@@ -0,0 +1,46 @@
+package interpreter
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/reilandeubank/golox/internal/corpus"
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+// FuzzInterpret runs programs that parse, with only the natives that don't reach outside
+// the interpreter and a step limit so that infinite loops end
+func FuzzInterpret(f *testing.F) {
+	corpus.Seeds(f,
+		"fun f(n) { return f(n + 1); } f(0);",
+		"var a = \"a\" + 1;",
+		"undefined = 1;",
+		"fun f() {} print f == f; print f == clock; print [f] == [f];",
+	)
+	f.Fuzz(func(t *testing.T, source string) {
+		thisScanner := scanner.NewScanner(source)
+		thisScanner.ErrorOutput = io.Discard
+		thisParser := parser.NewParser(thisScanner.ScanTokens())
+		thisParser.ErrorOutput = io.Discard
+		statements, err := thisParser.Parse()
+		if err != nil || len(thisScanner.Errors) > 0 || len(thisParser.Errors) > 0 {
+			return
+		}
+
+		i := NewInterpreter(
+			WithStdout(io.Discard),
+			WithStderr(io.Discard),
+			WithStdin(strings.NewReader("")),
+			WithCapabilities(),
+			WithStepLimit(10000),
+		)
+		err = i.Interpret(statements)
+		var runtimeErr *RuntimeError
+		if err != nil && !errors.As(err, &runtimeErr) && !errors.Is(err, ErrBudgetExceeded) {
+			t.Fatalf("Interpret returned %T: %v", err, err)
+		}
+	})
+}
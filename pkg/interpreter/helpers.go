@@ -8,7 +8,8 @@ import (
 	"github.com/reilandeubank/golox/pkg/scanner"
 )
 
-func isTruthy(object interface{}) bool {
+// IsTruthy reports whether a Lox value counts as true in a condition
+func IsTruthy(object interface{}) bool {
 	if object == nil || object == 0.0 || object == 0 { // only false, nil, and 0 are falsey
 		return false
 	}
@@ -20,10 +21,17 @@ func isTruthy(object interface{}) bool {
 	return true
 }
 
-func isEqual(a interface{}, b interface{}) bool {
+// IsEqual reports whether two Lox values are equal under ==. Functions and lists are
+// equal only to themselves
+func IsEqual(a interface{}, b interface{}) bool {
 	if a == nil && b == nil { // No implicit type conversion for equality, like Go
 		return true
-	} else if a == nil {
+	} else if a == nil || b == nil {
+		return false
+	}
+	// Values Go can't compare with == (such as a callable an embedder defined as a struct
+	// holding slices) are never equal, rather than panicking
+	if !reflect.TypeOf(a).Comparable() || !reflect.TypeOf(b).Comparable() {
 		return false
 	}
 	return a == b
@@ -43,7 +51,8 @@ func checkNumberOperands(operator scanner.Token, left interface{}, right interfa
 	return &RuntimeError{Token: operator, Message: "Operators must be numbers"}
 }
 
-func stringify(object interface{}) string {
+// Stringify renders a Lox value the way print does
+func Stringify(object interface{}) string {
 	if object == nil {
 		return "nil"
 	}
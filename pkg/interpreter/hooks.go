@@ -0,0 +1,146 @@
+package interpreter
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/reilandeubank/golox/pkg/parser"
+)
+
+// ErrHalted is returned by a hook to stop the program. Interpret returns it without
+// reporting it as a runtime error
+var ErrHalted = errors.New("execution halted")
+
+// Hook observes a running program. Hooks are called synchronously, so a hook that blocks
+// pauses the program, and while it is paused it may inspect the interpreter's frames
+type Hook interface {
+	// BeforeStmt is called before each statement is executed. Returning an error stops
+	// the program with that error
+	BeforeStmt(i *Interpreter, stmt parser.Stmt) error
+	// EnterCall is called once a function's frame has been pushed, before its body runs
+	EnterCall(i *Interpreter, function LoxFunction)
+	// ExitCall is called as a function returns, before its frame is popped
+	ExitCall(i *Interpreter, function LoxFunction)
+}
+
+// BranchHook may be implemented by a Hook that also follows control flow within statements
+type BranchHook interface {
+	// IfBranch is called once an if statement's condition is evaluated, with whether the
+	// then branch will run
+	IfBranch(i *Interpreter, stmt parser.IfStmt, then bool)
+	// LogicalBranch is called once the left operand of 'and' or 'or' is evaluated, with
+	// whether the right operand will be too
+	LogicalBranch(i *Interpreter, expr parser.Logical, right bool)
+}
+
+// LoopHook may be implemented by a Hook that also follows loops
+type LoopHook interface {
+	// NextIteration is called each time a while loop has run its body and is about to test
+	// its condition again
+	NextIteration(i *Interpreter, stmt parser.WhileStmt)
+}
+
+// WithHook adds a hook that is called as the interpreter runs
+func WithHook(hook Hook) Option {
+	return func(i *Interpreter) {
+		i.hooks = append(i.hooks, hook)
+		if branchHook, ok := hook.(BranchHook); ok {
+			i.branchHooks = append(i.branchHooks, branchHook)
+		}
+		if loopHook, ok := hook.(LoopHook); ok {
+			i.loopHooks = append(i.loopHooks, loopHook)
+		}
+	}
+}
+
+// Frame is an entry on the call stack: the top-level script or a function call
+type Frame struct {
+	Name string // the function name, or "<script>" for top-level code
+	Line int    // the line of the statement being executed
+	env  *environment
+}
+
+// Scopes returns the variables visible from the frame, innermost scope first and the
+// globals last
+func (f Frame) Scopes() []map[string]interface{} {
+	var scopes []map[string]interface{}
+	for env := f.env; env != nil; env = env.enclosing {
+		scope := make(map[string]interface{}, len(env.values))
+		for name, value := range env.values {
+			scope[name] = value
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+// Names returns the sorted names in a scope returned by Scopes
+func Names(scope map[string]interface{}) []string {
+	names := make([]string, 0, len(scope))
+	for name := range scope {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Frames returns the call stack, innermost frame first
+func (i *Interpreter) Frames() []Frame {
+	frames := make([]Frame, len(i.frames))
+	for j, frame := range i.frames {
+		frames[len(frames)-1-j] = *frame
+	}
+	return frames
+}
+
+// Depth returns the number of frames on the call stack
+func (i *Interpreter) Depth() int {
+	return len(i.frames)
+}
+
+// ScopeDepth returns the number of environments enclosing the current one, which is 0
+// while executing top-level code
+func (i *Interpreter) ScopeDepth() int {
+	depth := 0
+	for env := i.environment; env.enclosing != nil; env = env.enclosing {
+		depth++
+	}
+	return depth
+}
+
+// EvaluateIn evaluates expr in the environment of a frame returned by Frames, without
+// calling hooks or reporting errors. It is meant for inspecting a paused program
+func (i *Interpreter) EvaluateIn(frame Frame, expr parser.Expression) (value interface{}, err error) {
+	previous, hooks, branchHooks, loopHooks := i.environment, i.hooks, i.branchHooks, i.loopHooks
+	i.environment, i.hooks, i.branchHooks, i.loopHooks = frame.env, nil, nil, nil
+	defer func() {
+		i.environment, i.hooks, i.branchHooks, i.loopHooks = previous, hooks, branchHooks, loopHooks
+	}()
+	return i.evaluate(expr)
+}
+
+func (i *Interpreter) pushFrame(name string, line int) {
+	i.frames = append(i.frames, &Frame{Name: name, Line: line, env: i.environment})
+}
+
+func (i *Interpreter) popFrame() {
+	i.frames = i.frames[:len(i.frames)-1]
+}
+
+// beforeStmt records the position of the innermost frame, then runs the hooks
+func (i *Interpreter) beforeStmt(stmt parser.Stmt) error {
+	if len(i.frames) == 0 {
+		return nil
+	}
+	frame := i.frames[len(i.frames)-1]
+	frame.env = i.environment
+	if line := parser.StmtLine(stmt); line != 0 {
+		frame.Line = line
+	}
+	for _, hook := range i.hooks {
+		if err := hook.BeforeStmt(i, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
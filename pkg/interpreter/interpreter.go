@@ -1,7 +1,14 @@
 package interpreter
 
 import (
-	// "fmt"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
 	//"reflect"
 
 	"github.com/reilandeubank/golox/pkg/parser"
@@ -11,16 +18,76 @@ import (
 type Interpreter struct{
 	globals *environment
 	environment *environment
+	stdout io.Writer
+	stderr io.Writer
+	stdin *bufio.Reader
+	ctx context.Context
+	timeout time.Duration
+	maxSteps int
+	steps int
+	line int
+	capabilities map[Capability]bool // nil grants every capability
+	hooks []Hook
+	branchHooks []BranchHook
+	loopHooks []LoopHook
+	frames []*Frame
 }
 
-func NewInterpreter() Interpreter {
+// maxCallDepth bounds how deeply Lox calls may nest, so that runaway recursion is reported
+// as a runtime error rather than overflowing the Go stack
+const maxCallDepth = 10000
+
+// Option configures an Interpreter built by NewInterpreter
+type Option func(*Interpreter)
+
+// WithStdout sets the writer used by print and by natives that produce output
+func WithStdout(w io.Writer) Option {
+	return func(i *Interpreter) {
+		i.stdout = w
+	}
+}
+
+// WithStderr sets the writer runtime errors are reported to
+func WithStderr(w io.Writer) Option {
+	return func(i *Interpreter) {
+		i.stderr = w
+	}
+}
+
+// WithStdin sets the reader the program's input comes from
+func WithStdin(r io.Reader) Option {
+	return func(i *Interpreter) {
+		i.stdin = bufio.NewReader(r)
+	}
+}
+
+// Stdin returns the program's input, for natives an embedder defines with Define
+func (i *Interpreter) Stdin() *bufio.Reader {
+	return i.stdin
+}
+
+func NewInterpreter(opts ...Option) Interpreter {
 	global := NewEnvironment()
-	global.define("clock", &clock{})
-	global.define("toStr", &toStr{})
-	return Interpreter{environment: &global, globals: &global}
+	i := Interpreter{environment: &global, globals: &global, stdout: os.Stdout, stderr: os.Stderr}
+	for _, opt := range opts {
+		opt(&i)
+	}
+	if i.stdin == nil {
+		i.stdin = bufio.NewReader(os.Stdin)
+	}
+	i.defineNatives()
+	return i
 }
 
 func (i *Interpreter) execute(stmt parser.Stmt) (interface{}, error) {
+	err := i.tick(parser.StmtLine(stmt))
+	if err != nil {
+		return nil, err
+	}
+	err = i.beforeStmt(stmt)
+	if err != nil {
+		return nil, err
+	}
 	return stmt.Accept(i)
 }
 
@@ -29,15 +96,92 @@ func (i *Interpreter) evaluate(expr parser.Expression) (interface{}, error) {
 }
 
 func (i *Interpreter) Interpret(statements []parser.Stmt) error {
+	return i.InterpretContext(context.Background(), statements)
+}
+
+// InterpretContext is like Interpret but stops with a *BudgetError once ctx is done
+func (i *Interpreter) InterpretContext(ctx context.Context, statements []parser.Stmt) error {
+	defer i.begin(ctx)()
+	i.pushFrame("<script>", 0)
+	defer i.popFrame()
+
 	for _, stmt := range statements {
 		_, err := i.execute(stmt)
-		if err != nil {
+		if errors.Is(err, ErrHalted) {
+			return err
+		} else if err != nil {
+			i.runtimeError(err)
 			return err
 		}
 	}
 	return nil
 }
 
+// Evaluate evaluates a single expression in the current environment. Like Interpret, it
+// reports any runtime error before returning it
+func (i *Interpreter) Evaluate(expr parser.Expression) (interface{}, error) {
+	defer i.begin(context.Background())()
+	// The expression runs as top-level code, so that functions it calls can return
+	i.pushFrame("<script>", 0)
+	defer i.popFrame()
+
+	value, err := i.evaluate(expr)
+	if err != nil {
+		i.runtimeError(err)
+		return nil, err
+	}
+	return value, nil
+}
+
+// Define binds name to value in the global environment
+func (i *Interpreter) Define(name string, value interface{}) {
+	i.globals.define(name, value)
+}
+
+// Globals returns a copy of the bindings in the global environment
+func (i *Interpreter) Globals() map[string]interface{} {
+	globals := make(map[string]interface{}, len(i.globals.values))
+	for name, value := range i.globals.values {
+		globals[name] = value
+	}
+	return globals
+}
+
+// Names returns the sorted names defined in the current and global environments
+func (i *Interpreter) Names() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, name := range append(i.environment.names(), i.globals.names()...) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// begin resets the execution budget for a new top-level run under ctx, returning a
+// function that ends the run
+func (i *Interpreter) begin(ctx context.Context) func() {
+	cancel := func() {}
+	if i.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, i.timeout)
+	}
+	previous := i.ctx
+	i.ctx = ctx
+	i.steps = 0
+	return func() {
+		cancel()
+		i.ctx = previous
+	}
+}
+
+// runtimeError reports err to the interpreter's error output, mirroring scanner.Report for parse errors
+func (i *Interpreter) runtimeError(err error) {
+	fmt.Fprintln(i.stderr, err)
+}
+
 func (i *Interpreter) executeBlock(statements []parser.Stmt, environment environment) (interface{}, error) {
 	previous := i.environment
 	defer func() {
@@ -0,0 +1,146 @@
+package interpreter
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// runCaptured runs source with every kind of output captured: what the program prints,
+// and the syntax and runtime errors it causes
+func runCaptured(source string) (stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	thisScanner := scanner.NewScanner(source)
+	thisScanner.ErrorOutput = &errOut
+	thisParser := parser.NewParser(thisScanner.ScanTokens())
+	thisParser.ErrorOutput = &errOut
+	statements, err := thisParser.Parse()
+	if err != nil || len(thisScanner.Errors) > 0 || len(thisParser.Errors) > 0 {
+		return out.String(), errOut.String()
+	}
+
+	i := NewInterpreter(WithStdout(&out), WithStderr(&errOut), WithStdin(strings.NewReader("")))
+	i.Interpret(statements)
+	return out.String(), errOut.String()
+}
+
+// TestGolden compares the stdout and stderr of each testdata/golden/*.lox program with
+// the .out and .err files beside it. Run with -update to rewrite them
+func TestGolden(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "golden", "*.lox"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		name := strings.TrimSuffix(path, ".lox")
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			source, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stdout, stderr := runCaptured(string(source))
+			compareGolden(t, name+".out", stdout)
+			compareGolden(t, name+".err", stderr)
+		})
+	}
+}
+
+func compareGolden(t *testing.T, path string, got string) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs:\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// TestOutputIsPerInterpreter checks that interpreters running at the same time don't see
+// each other's output or errors
+func TestOutputIsPerInterpreter(t *testing.T) {
+	programs := map[string]struct{ source, stdout, stderr string }{
+		"prints":        {"print 1;", "1\n", ""},
+		"syntax error":  {"print ;", "", "[line 1] Parse Error at ';': expect expression\n"},
+		"runtime error": {"print 2; print x;", "2\n", "[line 1] Runtime Error: Undefined variable 'x'.\n"},
+	}
+	for name, program := range programs {
+		program := program
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			for n := 0; n < 100; n++ {
+				stdout, stderr := runCaptured(program.source)
+				if stdout != program.stdout || stderr != program.stderr {
+					t.Fatalf("got stdout %q and stderr %q, want %q and %q", stdout, stderr, program.stdout, program.stderr)
+				}
+			}
+		})
+	}
+}
+
+// TestEvaluateCall calls a function the way the REPL does for an expression, which must
+// be able to return like any other call
+func TestEvaluateCall(t *testing.T) {
+	var out, errOut bytes.Buffer
+	i := NewInterpreter(WithStdout(&out), WithStderr(&errOut))
+	if err := i.Interpret(parse(t, "fun add(a, b) { return a + b; }")); err != nil {
+		t.Fatal(err)
+	}
+
+	thisScanner := scanner.NewScanner("add(1, 2)")
+	thisParser := parser.NewParser(thisScanner.ScanTokens())
+	expr, err := thisParser.ParseExpression()
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := i.Evaluate(expr)
+	if err != nil || value != 3.0 {
+		t.Errorf("got %v and error %v, want 3", value, err)
+	}
+	if errOut.Len() > 0 {
+		t.Errorf("got error output %q", errOut.String())
+	}
+	if frames := i.Frames(); len(frames) != 0 {
+		t.Errorf("%d frames left on the stack", len(frames))
+	}
+}
+
+func TestIsEqual(t *testing.T) {
+	// A value Go can't compare, as an embedder might Define
+	type uncomparable struct{ values []int }
+	list := NewLoxList([]interface{}{1.0})
+	tests := []struct {
+		a, b interface{}
+		want bool
+	}{
+		{nil, nil, true},
+		{nil, 1.0, false},
+		{1.0, nil, false},
+		{1.0, 1.0, true},
+		{"a", "a", true},
+		{1.0, "1", false},
+		{list, list, true},
+		{list, NewLoxList([]interface{}{1.0}), false},
+		{uncomparable{}, uncomparable{}, false},
+		{uncomparable{}, 1.0, false},
+	}
+	for _, test := range tests {
+		if got := IsEqual(test.a, test.b); got != test.want {
+			t.Errorf("IsEqual(%v, %v) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
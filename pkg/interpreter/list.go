@@ -0,0 +1,23 @@
+package interpreter
+
+import (
+	"strings"
+)
+
+// LoxList is an ordered sequence of values, such as the result of split. Lists are
+// shared by reference, like functions
+type LoxList struct {
+	Elements []interface{}
+}
+
+func NewLoxList(elements []interface{}) *LoxList {
+	return &LoxList{Elements: elements}
+}
+
+func (l *LoxList) String() string {
+	parts := make([]string, len(l.Elements))
+	for j, element := range l.Elements {
+		parts[j] = Stringify(element)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
@@ -0,0 +1,127 @@
+package interpreter
+
+import (
+	"math"
+)
+
+// mathConstants are defined in the globals alongside the natives. Programs can't assign
+// to them or declare globals of the same names, though locals may shadow them
+var mathConstants = map[string]float64{
+	"PI":  math.Pi,
+	"E":   math.E,
+	"INF": math.Inf(1),
+	"NAN": math.NaN(),
+}
+
+// numbers returns the arguments to the native name as numbers, or a runtime error if any
+// of them isn't one
+func numbers(name string, arguments []interface{}) ([]float64, error) {
+	values := make([]float64, len(arguments))
+	for j, argument := range arguments {
+		value, ok := argument.(float64)
+		if !ok {
+			if len(arguments) == 1 {
+				return nil, &RuntimeError{Message: name + ": argument must be a number."}
+			}
+			return nil, &RuntimeError{Message: name + ": arguments must be numbers."}
+		}
+		values[j] = value
+	}
+	return values, nil
+}
+
+// mathFunc is a native applying a function to one number, such as sqrt
+type mathFunc struct {
+	name string
+	fn   func(float64) float64
+}
+
+func (m *mathFunc) Arity() (int, int) {
+	return 1, 1
+}
+
+func (m *mathFunc) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	x, err := numbers(m.name, arguments)
+	if err != nil {
+		return nil, err
+	}
+	return m.fn(x[0]), nil
+}
+
+func (m mathFunc) String() string {
+	return "<native fn>"
+}
+
+// mathFunc2 is a native applying a function to two numbers, such as pow
+type mathFunc2 struct {
+	name string
+	fn   func(float64, float64) float64
+}
+
+func (m *mathFunc2) Arity() (int, int) {
+	return 2, 2
+}
+
+func (m *mathFunc2) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	x, err := numbers(m.name, arguments)
+	if err != nil {
+		return nil, err
+	}
+	return m.fn(x[0], x[1]), nil
+}
+
+func (m mathFunc2) String() string {
+	return "<native fn>"
+}
+
+// mathTest is a native reporting whether a number has some property, such as isNaN
+type mathTest struct {
+	name string
+	fn   func(float64) bool
+}
+
+func (m *mathTest) Arity() (int, int) {
+	return 1, 1
+}
+
+func (m *mathTest) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	x, err := numbers(m.name, arguments)
+	if err != nil {
+		return nil, err
+	}
+	return m.fn(x[0]), nil
+}
+
+func (m mathTest) String() string {
+	return "<native fn>"
+}
+
+// extremum is min or max, which take one or more numbers
+type extremum struct {
+	name string
+	pick func(float64, float64) float64
+}
+
+func (e *extremum) Arity() (int, int) {
+	return 1, Variadic
+}
+
+func (e *extremum) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	x, err := numbers(e.name, arguments)
+	if err != nil {
+		return nil, err
+	}
+	result := x[0]
+	for _, value := range x[1:] {
+		result = e.pick(result, value)
+	}
+	return result, nil
+}
+
+func (e extremum) String() string {
+	return "<native fn>"
+}
+
+func isInf(x float64) bool {
+	return math.IsInf(x, 0)
+}
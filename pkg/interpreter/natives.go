@@ -0,0 +1,132 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+)
+
+// Capability names a group of natives that reach outside the interpreter
+type Capability string
+
+// Only CapTime gates a native so far, clock. CapIO, CapFS, CapOS and CapRandom are
+// reserved for natives still to come, so granting or withholding them changes nothing yet
+const (
+	CapIO     Capability = "io"
+	CapFS     Capability = "fs"
+	CapOS     Capability = "os"
+	CapTime   Capability = "time"
+	CapRandom Capability = "random"
+)
+
+// AllCapabilities is the set granted when no WithCapabilities option is given
+var AllCapabilities = []Capability{CapIO, CapFS, CapOS, CapTime, CapRandom}
+
+type native struct {
+	name       string
+	capability Capability // empty for natives that are always available
+	callable   LoxCallable
+}
+
+var natives = []native{
+	{"clock", CapTime, &clock{}},
+	{"toStr", "", &toStr{}},
+	{"len", "", &length{}},
+	{"substr", "", &substr{}},
+	{"indexOf", "", &indexOf{}},
+	{"contains", "", &contains{}},
+	{"startsWith", "", &startsWith{}},
+	{"endsWith", "", &endsWith{}},
+	{"split", "", &split{}},
+	{"join", "", &join{}},
+	{"upper", "", &upper{}},
+	{"lower", "", &lower{}},
+	{"trim", "", &trim{}},
+	{"replace", "", &replace{}},
+	{"repeat", "", &repeat{}},
+	{"charAt", "", &charAt{}},
+	{"ord", "", &ord{}},
+	{"chr", "", &chr{}},
+	{"parseNumber", "", &parseNumber{}},
+	{"format", "", &format{}},
+	{"printf", "", &printf{}},
+
+	{"sqrt", "", &mathFunc{"sqrt", math.Sqrt}},
+	{"pow", "", &mathFunc2{"pow", math.Pow}},
+	{"abs", "", &mathFunc{"abs", math.Abs}},
+	{"floor", "", &mathFunc{"floor", math.Floor}},
+	{"ceil", "", &mathFunc{"ceil", math.Ceil}},
+	{"round", "", &mathFunc{"round", math.Round}},
+	{"trunc", "", &mathFunc{"trunc", math.Trunc}},
+	{"min", "", &extremum{"min", math.Min}},
+	{"max", "", &extremum{"max", math.Max}},
+	{"sin", "", &mathFunc{"sin", math.Sin}},
+	{"cos", "", &mathFunc{"cos", math.Cos}},
+	{"tan", "", &mathFunc{"tan", math.Tan}},
+	{"atan2", "", &mathFunc2{"atan2", math.Atan2}},
+	{"log", "", &mathFunc{"log", math.Log}},
+	{"log10", "", &mathFunc{"log10", math.Log10}},
+	{"exp", "", &mathFunc{"exp", math.Exp}},
+	{"isNaN", "", &mathTest{"isNaN", math.IsNaN}},
+	{"isInf", "", &mathTest{"isInf", isInf}},
+}
+
+// WithCapabilities grants only caps to the program. Every native is still defined, so
+// scripts can refer to them, but calling one that needs a capability outside caps is a
+// "Capability not granted" runtime error
+func WithCapabilities(caps ...Capability) Option {
+	return func(i *Interpreter) {
+		i.capabilities = make(map[Capability]bool)
+		for _, c := range caps {
+			i.capabilities[c] = true
+		}
+	}
+}
+
+func (i *Interpreter) defineNatives() {
+	for _, n := range natives {
+		if n.capability == "" || i.capabilities == nil || i.capabilities[n.capability] {
+			i.globals.define(n.name, n.callable)
+		} else {
+			i.globals.define(n.name, &deniedNative{native: n})
+		}
+	}
+	for name, value := range mathConstants {
+		i.globals.defineConstant(name, value)
+	}
+}
+
+// Natives returns the built-in functions every interpreter defines, by name
+func Natives() map[string]LoxCallable {
+	callables := make(map[string]LoxCallable, len(natives))
+	for _, n := range natives {
+		callables[n.name] = n.callable
+	}
+	return callables
+}
+
+// Constants returns the built-in numeric globals, such as PI
+func Constants() map[string]float64 {
+	values := make(map[string]float64, len(mathConstants))
+	for name, value := range mathConstants {
+		values[name] = value
+	}
+	return values
+}
+
+// deniedNative stands in for a native whose capability was not granted
+type deniedNative struct {
+	native native
+}
+
+func (d *deniedNative) Arity() (int, int) {
+	return d.native.callable.Arity()
+}
+
+func (d *deniedNative) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+	message := fmt.Sprintf("Capability not granted: %s() requires '%s'.", d.native.name, d.native.capability)
+	return nil, &RuntimeError{Message: message}
+}
+
+func (d deniedNative) String() string {
+	return "<native fn>"
+}
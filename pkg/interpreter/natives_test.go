@@ -0,0 +1,89 @@
+package interpreter
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+func TestCapabilities(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		source  string
+		output  string
+		message string // of the runtime error, if the program should fail
+	}{
+		{"every capability by default", nil, "print clock() > 0;", "true\n", ""},
+		{"granted", []Option{WithCapabilities(CapTime)}, "print clock() > 0;", "true\n", ""},
+		{"denied", []Option{WithCapabilities()}, "print 1;\nclock();", "1\n", "Capability not granted: clock() requires 'time'."},
+		{"only time denied", []Option{WithCapabilities(CapIO, CapFS, CapOS, CapRandom)}, "print len(\"ab\");\nclock();", "2\n", "Capability not granted: clock() requires 'time'."},
+		{"denied but defined", []Option{WithCapabilities(CapIO)}, "print clock;", "<native fn>\n", ""},
+		{"needing no capability", []Option{WithCapabilities()}, `print len("abc");`, "3\n", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout strings.Builder
+			i := NewInterpreter(append(test.opts, WithStdout(&stdout), WithStderr(io.Discard))...)
+			err := i.Interpret(parse(t, test.source))
+			if stdout.String() != test.output {
+				t.Errorf("printed %q, want %q", stdout.String(), test.output)
+			}
+			if test.message == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			var runtimeErr *RuntimeError
+			if !errors.As(err, &runtimeErr) {
+				t.Fatalf("got %v, want a RuntimeError", err)
+			}
+			if runtimeErr.Message != test.message || runtimeErr.Token.Line != 2 {
+				t.Errorf("got %q on line %d, want %q on line 2", runtimeErr.Message, runtimeErr.Token.Line, test.message)
+			}
+		})
+	}
+}
+
+// TestDeniedCapabilities checks that withholding a capability hides every native that
+// needs it, and only those
+func TestDeniedCapabilities(t *testing.T) {
+	for _, denied := range AllCapabilities {
+		t.Run(string(denied), func(t *testing.T) {
+			var granted []Capability
+			for _, c := range AllCapabilities {
+				if c != denied {
+					granted = append(granted, c)
+				}
+			}
+			i := NewInterpreter(WithCapabilities(granted...), WithStdout(io.Discard), WithStderr(io.Discard))
+			for _, n := range natives {
+				value, err := i.globals.get(scanner.Token{Lexeme: n.name})
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, hidden := value.(*deniedNative)
+				if want := n.capability == denied; hidden != want {
+					t.Errorf("%s() hidden = %v, want %v", n.name, hidden, want)
+				}
+			}
+		})
+	}
+}
+
+// TestNativeErrorLines checks that each failing call to a native reports its own line
+func TestNativeErrorLines(t *testing.T) {
+	i := NewInterpreter(WithStdout(io.Discard), WithStderr(io.Discard), WithCapabilities())
+	for _, source := range []string{"clock();", "\nclock();", "\n\nclock();"} {
+		want := strings.Count(source, "\n") + 1
+		err := i.Interpret(parse(t, source))
+		var runtimeErr *RuntimeError
+		if !errors.As(err, &runtimeErr) || runtimeErr.Token.Line != want {
+			t.Errorf("got %v, want an error on line %d", err, want)
+		}
+	}
+}
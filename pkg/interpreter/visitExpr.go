@@ -3,6 +3,8 @@ package interpreter
 import (
 	"reflect"
 	"fmt"
+	"math"
+	"unicode/utf8"
 	"github.com/reilandeubank/golox/pkg/parser"
 	"github.com/reilandeubank/golox/pkg/scanner"
 )
@@ -29,7 +31,7 @@ func (i *Interpreter) VisitUnaryExpr(unary parser.Unary) (interface{}, error) {
 		}
 		return -(right.(float64)), nil
 	case scanner.BANG:
-		return !isTruthy(right), nil
+		return !IsTruthy(right), nil
 	}
 
 	return nil, nil
@@ -71,7 +73,7 @@ func (i *Interpreter) VisitBinaryExpr(binary parser.Binary) (interface{}, error)
 		if reflect.TypeOf(left) == reflect.TypeOf(0.0) && reflect.TypeOf(right) == reflect.TypeOf(0.0) {
 			return left.(float64) + right.(float64), nil
 		}
-		return nil, err
+		return nil, &RuntimeError{Token: binary.Operator, Message: "Operands must be two numbers or two strings."}
 	case scanner.GREATER:
 		err = checkNumberOperands(binary.Operator, left, right)
 		if err != nil {
@@ -97,9 +99,9 @@ func (i *Interpreter) VisitBinaryExpr(binary parser.Binary) (interface{}, error)
 		}
 		return left.(float64) <= right.(float64), nil
 	case scanner.BANG_EQUAL:
-		return !isEqual(left, right), nil
+		return !IsEqual(left, right), nil
 	case scanner.EQUAL_EQUAL:
-		return isEqual(left, right), nil
+		return IsEqual(left, right), nil
 	}
 
 	return nil, nil // unreachable
@@ -115,7 +117,10 @@ func (i *Interpreter) VisitAssignExpr(expr parser.Assign) (interface{}, error) {
 		return nil, err
 	}
 
-	i.environment.assign(expr.Name, value)
+	err = i.environment.assign(expr.Name, value)
+	if err != nil {
+		return nil, err
+	}
 	return value, nil
 }
 
@@ -125,14 +130,15 @@ func (i *Interpreter) VisitLogicalExpr(expr parser.Logical) (interface{}, error)
 		return nil, err
 	}
 
-	if expr.Operator.Type == scanner.OR {
-		if isTruthy(left) { // short-circuiting
-			return left, nil
-		}
-	} else {
-		if !isTruthy(left) { // short-circuiting
-			return left, nil
-		}
+	shortCircuit := IsTruthy(left)
+	if expr.Operator.Type != scanner.OR {
+		shortCircuit = !shortCircuit
+	}
+	for _, hook := range i.branchHooks {
+		hook.LogicalBranch(i, expr, !shortCircuit)
+	}
+	if shortCircuit {
+		return left, nil
 	}
 
 	return i.evaluate(expr.Right)
@@ -157,9 +163,57 @@ func (i *Interpreter) VisitCallExpr(expr parser.Call) (interface{}, error) {
 		return nil, &RuntimeError{Token: expr.Paren, Message: "Can only call functions."}
 	}
 
-	if len(arguments) != function.Arity() {
-		return nil, &RuntimeError{Token: expr.Paren, Message: "Expected " + fmt.Sprint(function.Arity()) + " arguments but got " + fmt.Sprint(len(arguments)) + "."}
+	if !Accepts(function, len(arguments)) {
+		return nil, &RuntimeError{Token: expr.Paren, Message: "Expected " + ArityString(function) + " arguments but got " + fmt.Sprint(len(arguments)) + "."}
+	}
+
+	err = i.tick(expr.Paren.Line)
+	if err != nil {
+		return nil, err
+	}
+	if len(i.frames) > maxCallDepth {
+		return nil, &RuntimeError{Token: expr.Paren, Message: "Stack overflow."}
+	}
+
+	value, err := function.Call(i, arguments)
+	if runtimeErr, ok := err.(*RuntimeError); ok && runtimeErr.Token.Line == 0 {
+		// Natives don't know where they were called from
+		err = &RuntimeError{Token: expr.Paren, Message: runtimeErr.Message}
+	}
+	return value, err
+}
+
+func (i *Interpreter) VisitIndexExpr(expr parser.Index) (interface{}, error) {
+	object, err := i.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.evaluate(expr.Index)
+	if err != nil {
+		return nil, err
 	}
 
-	return function.Call(i, arguments)
-}
\ No newline at end of file
+	var length int
+	var what string
+	switch value := object.(type) {
+	case *LoxList:
+		length, what = len(value.Elements), "list"
+	case string:
+		length, what = utf8.RuneCountInString(value), "string"
+	default:
+		return nil, &RuntimeError{Token: expr.Bracket, Message: "Only lists and strings can be indexed."}
+	}
+
+	n, ok := index.(float64)
+	if !ok || n != math.Trunc(n) {
+		return nil, &RuntimeError{Token: expr.Bracket, Message: "Index must be a whole number."}
+	}
+	if n < 0 || n >= float64(length) {
+		return nil, &RuntimeError{Token: expr.Bracket, Message: fmt.Sprintf("Index %s is out of range for a %s of length %d.", Stringify(n), what, length)}
+	}
+
+	if list, ok := object.(*LoxList); ok {
+		return list.Elements[int(n)], nil
+	}
+	return string([]rune(object.(string))[int(n)]), nil
+}
@@ -17,7 +17,7 @@ func (i *Interpreter) VisitPrintStmt(printStmt parser.PrintStmt) (interface{}, e
 	if err != nil {
 		return nil, err
 	}
-	fmt.Println(stringify(value))
+	fmt.Fprintln(i.stdout, Stringify(value))
 	return nil, nil
 }
 
@@ -30,6 +30,10 @@ func (i *Interpreter) VisitVarStmt(varStmt parser.VarStmt) (interface{}, error)
 			return nil, err
 		}
 	}
+	err = i.environment.redefine(varStmt.Name)
+	if err != nil {
+		return nil, err
+	}
 	i.environment.define(varStmt.Name.Lexeme, value)
 	return nil, nil
 }
@@ -43,7 +47,10 @@ func (i *Interpreter) VisitIfStmt(ifStmt parser.IfStmt) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	if isTruthy(condition) {
+	for _, hook := range i.branchHooks {
+		hook.IfBranch(i, ifStmt, IsTruthy(condition))
+	}
+	if IsTruthy(condition) {
 		return i.execute(ifStmt.ThenBranch)
 	} else if ifStmt.ElseBranch != nil {
 		return i.execute(ifStmt.ElseBranch)
@@ -58,7 +65,7 @@ func (i *Interpreter) VisitWhileStmt(whileStmt parser.WhileStmt) (interface{}, e
 			return nil, err
 		}
 
-		if !isTruthy(condition) {
+		if !IsTruthy(condition) {
 			break
 		}
 
@@ -66,18 +73,31 @@ func (i *Interpreter) VisitWhileStmt(whileStmt parser.WhileStmt) (interface{}, e
 		if err != nil {
 			return nil, err
 		}
+		for _, hook := range i.loopHooks {
+			hook.NextIteration(i, whileStmt)
+		}
 	}
 
 	return nil, nil
 }
 
 func (i *Interpreter) VisitFunctionStmt(functionStmt parser.FunctionStmt) (interface{}, error) {
-	function := LoxFunction{Declaration: functionStmt, Closure: i.environment, IsInitializer: false}
+	err := i.environment.redefine(functionStmt.Name)
+	if err != nil {
+		return nil, err
+	}
+	// Functions are stored by reference, so that == compares them by identity
+	function := &LoxFunction{Declaration: functionStmt, Closure: i.environment, IsInitializer: false}
 	i.environment.define(functionStmt.Name.Lexeme, function)
 	return nil, nil
 }
 
 func (i *Interpreter) VisitReturnStmt(returnStmt parser.ReturnStmt) (interface{}, error) {
+	// The parser rejects this, but a tree from elsewhere (such as golox exec) may not have
+	// been through it
+	if len(i.frames) < 2 {
+		return nil, &RuntimeError{Token: returnStmt.Keyword, Message: "Can't return from top-level code."}
+	}
 	var value interface{}
 	var err error
 	if returnStmt.Value != nil {
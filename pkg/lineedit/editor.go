@@ -0,0 +1,401 @@
+// Package lineedit is a small readline-style line editor for the REPL: cursor movement,
+// history with reverse search, and tab completion. When input is not a terminal it
+// falls back to reading plain lines.
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrInterrupted is returned by Prompt when the user abandons a line with Ctrl-C
+var ErrInterrupted = errors.New("interrupted")
+
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyCtrlH     = 8
+	keyTab       = 9
+	keyCtrlJ     = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// Escape sequences are decoded into runes past the Unicode range
+const (
+	keyUp rune = unicode.MaxRune + 1 + iota
+	keyDown
+	keyRight
+	keyLeft
+	keyHome
+	keyEnd
+	keyDelete
+	keyUnknown
+)
+
+type Editor struct {
+	// Complete returns the candidate words for the word being typed. It may return
+	// candidates that don't start with word; they are filtered out
+	Complete func(word string) []string
+
+	in      *os.File
+	reader  *bufio.Reader
+	out     io.Writer
+	history *history
+}
+
+// New returns an editor reading keys from in and drawing to out
+func New(in *os.File, out io.Writer) *Editor {
+	return &Editor{in: in, reader: bufio.NewReader(in), out: out, history: &history{}}
+}
+
+// SetHistoryFile loads previous history from path and appends every new entry to it. The
+// file is cut back to the newest entries when it is opened and when the editor is closed
+func (e *Editor) SetHistoryFile(path string) error {
+	return e.history.open(path)
+}
+
+// AddHistory records line so it can be recalled with the arrow keys and Ctrl-R
+func (e *Editor) AddHistory(line string) {
+	e.history.add(line)
+}
+
+// Close trims and releases the history file
+func (e *Editor) Close() error {
+	return e.history.close()
+}
+
+// Prompt shows prompt and returns the line entered, without its line terminator. It
+// returns io.EOF on Ctrl-D at an empty line, or at the end of non-terminal input
+func (e *Editor) Prompt(prompt string) (string, error) {
+	fmt.Fprint(e.out, prompt)
+	if !isTerminal(e.in.Fd()) {
+		return e.readPlain()
+	}
+
+	old, err := makeRaw(e.in.Fd())
+	if err != nil {
+		return e.readPlain()
+	}
+	defer setState(e.in.Fd(), old)
+
+	l := &lineState{editor: e, prompt: prompt, historyPos: len(e.history.entries)}
+	return l.edit()
+}
+
+func (e *Editor) readPlain() (string, error) {
+	line, err := e.reader.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// readKey reads one keypress, decoding the escape sequences for arrow and editing keys
+func (e *Editor) readKey() (rune, error) {
+	r, _, err := e.reader.ReadRune()
+	if err != nil || r != keyEscape {
+		return r, err
+	}
+	if e.reader.Buffered() == 0 {
+		return keyEscape, nil
+	}
+	next, _, err := e.reader.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if next != '[' && next != 'O' {
+		return keyUnknown, nil
+	}
+	code, _, err := e.reader.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	switch code {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		return keyRight, nil
+	case 'D':
+		return keyLeft, nil
+	case 'H':
+		return keyHome, nil
+	case 'F':
+		return keyEnd, nil
+	}
+	if code < '0' || code > '9' {
+		return keyUnknown, nil
+	}
+	// Sequences like ESC [ 3 ~ carry a number terminated by '~'
+	number := string(code)
+	for {
+		r, _, err := e.reader.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if r == '~' {
+			break
+		}
+		if r < '0' || r > '9' && r != ';' {
+			return keyUnknown, nil
+		}
+		number += string(r)
+	}
+	switch number {
+	case "1", "7":
+		return keyHome, nil
+	case "4", "8":
+		return keyEnd, nil
+	case "3":
+		return keyDelete, nil
+	}
+	return keyUnknown, nil
+}
+
+// lineState is the line being edited by one call to Prompt
+type lineState struct {
+	editor     *Editor
+	prompt     string
+	buf        []rune
+	pos        int
+	historyPos int
+	saved      []rune // the unfinished line, kept while browsing history
+}
+
+func (l *lineState) edit() (string, error) {
+	for {
+		key, err := l.editor.readKey()
+		if err != nil {
+			return "", err
+		}
+		switch key {
+		case keyEnter, keyCtrlJ:
+			fmt.Fprint(l.editor.out, "\n")
+			return string(l.buf), nil
+		case keyCtrlC:
+			fmt.Fprint(l.editor.out, "^C\n")
+			return "", ErrInterrupted
+		case keyCtrlD:
+			if len(l.buf) == 0 {
+				fmt.Fprint(l.editor.out, "\n")
+				return "", io.EOF
+			}
+			l.deleteAt(l.pos)
+		case keyBackspace, keyCtrlH:
+			if l.pos > 0 {
+				l.pos--
+				l.deleteAt(l.pos)
+			}
+		case keyDelete:
+			l.deleteAt(l.pos)
+		case keyLeft, keyCtrlB:
+			if l.pos > 0 {
+				l.pos--
+			}
+		case keyRight, keyCtrlF:
+			if l.pos < len(l.buf) {
+				l.pos++
+			}
+		case keyHome, keyCtrlA:
+			l.pos = 0
+		case keyEnd, keyCtrlE:
+			l.pos = len(l.buf)
+		case keyCtrlK:
+			l.buf = l.buf[:l.pos]
+		case keyCtrlU:
+			l.buf = l.buf[l.pos:]
+			l.pos = 0
+		case keyCtrlW:
+			start := l.pos
+			for start > 0 && l.buf[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && l.buf[start-1] != ' ' {
+				start--
+			}
+			l.buf = append(l.buf[:start], l.buf[l.pos:]...)
+			l.pos = start
+		case keyCtrlL:
+			fmt.Fprint(l.editor.out, "\x1b[H\x1b[2J")
+		case keyUp, keyCtrlP:
+			l.browse(-1)
+		case keyDown, keyCtrlN:
+			l.browse(1)
+		case keyTab:
+			l.complete()
+		case keyCtrlR:
+			line, done, err := l.search()
+			if done || err != nil {
+				return line, err
+			}
+		default:
+			if key >= ' ' && key <= unicode.MaxRune {
+				l.insert(key)
+			}
+		}
+		l.refresh()
+	}
+}
+
+func (l *lineState) insert(runes ...rune) {
+	tail := append([]rune{}, l.buf[l.pos:]...)
+	l.buf = append(append(l.buf[:l.pos], runes...), tail...)
+	l.pos += len(runes)
+}
+
+func (l *lineState) deleteAt(pos int) {
+	if pos < len(l.buf) {
+		l.buf = append(l.buf[:pos], l.buf[pos+1:]...)
+	}
+}
+
+// refresh redraws the prompt and line, leaving the terminal cursor at pos
+func (l *lineState) refresh() {
+	column := len([]rune(l.prompt)) + l.pos
+	fmt.Fprintf(l.editor.out, "\r%s%s\x1b[K\r", l.prompt, string(l.buf))
+	if column > 0 {
+		fmt.Fprintf(l.editor.out, "\x1b[%dC", column)
+	}
+}
+
+// browse moves delta entries through history, newest last
+func (l *lineState) browse(delta int) {
+	entries := l.editor.history.entries
+	next := l.historyPos + delta
+	if next < 0 || next > len(entries) {
+		return
+	}
+	if l.historyPos == len(entries) {
+		l.saved = append([]rune{}, l.buf...)
+	}
+	l.historyPos = next
+	if next == len(entries) {
+		l.buf = append([]rune{}, l.saved...)
+	} else {
+		l.buf = []rune(entries[next])
+	}
+	l.pos = len(l.buf)
+}
+
+// complete extends the word before the cursor to the longest prefix shared by its
+// completions, listing them when it can't be extended
+func (l *lineState) complete() {
+	if l.editor.Complete == nil {
+		return
+	}
+	start := l.pos
+	for start > 0 && isWordRune(l.buf[start-1]) {
+		start--
+	}
+	word := string(l.buf[start:l.pos])
+
+	extension, matches := completion(word, l.editor.Complete(word))
+	if len(extension) > 0 {
+		l.insert(extension...)
+	} else if len(matches) > 0 {
+		fmt.Fprintf(l.editor.out, "\n%s\n", strings.Join(matches, "  "))
+	}
+}
+
+// completion returns what to insert after word to complete it, which is empty if it
+// can't be extended, and the sorted candidates that start with word. Words are compared
+// by rune so that a multi-byte character is never split
+func completion(word string, candidates []string) ([]rune, []string) {
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			matches = append(matches, candidate)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	sort.Strings(matches)
+
+	common := []rune(matches[0])
+	for _, match := range matches[1:] {
+		runes := []rune(match)
+		n := 0
+		for n < len(common) && n < len(runes) && common[n] == runes[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	if len(matches) == 1 {
+		common = append(common, ' ')
+	}
+	return common[len([]rune(word)):], matches
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// search runs Ctrl-R incremental reverse search. Enter submits the match (done is true),
+// Ctrl-G or Ctrl-C cancels, and any other editing key keeps the match for further editing
+func (l *lineState) search() (line string, done bool, err error) {
+	var query []rune
+	match := -1
+	from := len(l.editor.history.entries)
+	for {
+		found := ""
+		if match >= 0 {
+			found = l.editor.history.entries[match]
+		}
+		fmt.Fprintf(l.editor.out, "\r(reverse-i-search)`%s': %s\x1b[K", string(query), found)
+
+		key, err := l.editor.readKey()
+		if err != nil {
+			return "", false, err
+		}
+		switch {
+		case key == keyCtrlR:
+			if match >= 0 {
+				from = match
+			}
+		case key == keyBackspace || key == keyCtrlH:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+			}
+			from = len(l.editor.history.entries)
+		case key == keyCtrlG || key == keyCtrlC:
+			return "", false, nil
+		case key == keyEnter || key == keyCtrlJ:
+			fmt.Fprint(l.editor.out, "\n")
+			return found, true, nil
+		case key >= ' ' && key <= unicode.MaxRune:
+			query = append(query, key)
+		default:
+			if match >= 0 {
+				l.buf = []rune(found)
+				l.pos = len(l.buf)
+				l.historyPos = match
+			}
+			return "", false, nil
+		}
+		if next := l.editor.history.search(string(query), from); next >= 0 || len(query) == 0 {
+			match = next
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package lineedit
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompletion(t *testing.T) {
+	tests := []struct {
+		word       string
+		candidates []string
+		extension  string
+		matches    []string
+	}{
+		{"pr", []string{"print", "var", "printf"}, "int", []string{"print", "printf"}},
+		{"va", []string{"var", "print"}, "r ", []string{"var"}},
+		{"print", []string{"print", "printf"}, "", []string{"print", "printf"}},
+		{"x", []string{"print"}, "", nil},
+		// Shared prefixes end on a whole character, not partway through its bytes
+		{"caf", []string{"café", "cafés"}, "é", []string{"café", "cafés"}},
+		{"ñ", []string{"ñandú", "ñame"}, "a", []string{"ñame", "ñandú"}},
+		{"", []string{"éa", "èb"}, "", []string{"èb", "éa"}}, // é and è share their first byte
+		{"ä", []string{"äb"}, "b ", []string{"äb"}},
+	}
+	for _, test := range tests {
+		extension, matches := completion(test.word, test.candidates)
+		if string(extension) != test.extension || strings.Join(matches, ",") != strings.Join(test.matches, ",") {
+			t.Errorf("completion(%q, %q) = %q, %q, want %q, %q", test.word, test.candidates, string(extension), matches, test.extension, test.matches)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package lineedit
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// maxHistory bounds how many entries are loaded from the history file and how many are
+// kept in it
+const maxHistory = 1000
+
+type history struct {
+	entries []string
+	file    *os.File
+	written int // how many entries the file holds
+}
+
+func (h *history) open(path string) error {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	lines := bufio.NewScanner(file)
+	for lines.Scan() {
+		if lines.Text() != "" {
+			h.entries = append(h.entries, lines.Text())
+		}
+	}
+	h.file = file
+	h.written = len(h.entries)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+	if err := lines.Err(); err != nil {
+		return err
+	}
+	return h.trim()
+}
+
+// add records line unless it is blank or repeats the previous entry
+func (h *history) add(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if len(h.entries) > 0 && h.entries[len(h.entries)-1] == line {
+		return
+	}
+	h.entries = append(h.entries, line)
+	if h.file != nil {
+		h.file.WriteString(line + "\n")
+		h.written++
+	}
+}
+
+// trim rewrites the history file with only the newest maxHistory entries once it has
+// grown past that
+func (h *history) trim() error {
+	if h.file == nil || h.written <= maxHistory {
+		return nil
+	}
+	keep := h.entries
+	if len(keep) > maxHistory {
+		keep = keep[len(keep)-maxHistory:]
+	}
+	// The file is opened for appending, so after truncating it writes start from the top
+	if err := h.file.Truncate(0); err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(h.file)
+	for _, entry := range keep {
+		writer.WriteString(entry + "\n")
+	}
+	h.written = len(keep)
+	return writer.Flush()
+}
+
+// search returns the index of the newest entry before from that contains query, or -1
+func (h *history) search(query string, from int) int {
+	for j := from - 1; j >= 0; j-- {
+		if strings.Contains(h.entries[j], query) {
+			return j
+		}
+	}
+	return -1
+}
+
+// close trims the history file and releases it
+func (h *history) close() error {
+	if h.file == nil {
+		return nil
+	}
+	err := h.trim()
+	if closeErr := h.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+package lineedit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	h := &history{}
+	if err := h.open(path); err != nil {
+		t.Fatal(err)
+	}
+	h.add("print 1;")
+	h.add("print 1;") // repeats the previous entry
+	h.add("   ")
+	h.add("print 2;")
+	if err := h.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"print 1;", "print 2;"}
+	if got := readLines(t, path); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("file holds %q, want %q", got, want)
+	}
+
+	reopened := &history{}
+	if err := reopened.open(path); err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.close()
+	if strings.Join(reopened.entries, "|") != strings.Join(want, "|") {
+		t.Errorf("loaded %q, want %q", reopened.entries, want)
+	}
+}
+
+func TestHistoryFileIsTrimmed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	var old strings.Builder
+	for n := 0; n < maxHistory+500; n++ {
+		fmt.Fprintf(&old, "old %d\n", n)
+	}
+	if err := os.WriteFile(path, []byte(old.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	h := &history{}
+	if err := h.open(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.entries) != maxHistory || h.entries[0] != "old 500" {
+		t.Fatalf("loaded %d entries starting with %q, want %d starting with %q", len(h.entries), h.entries[0], maxHistory, "old 500")
+	}
+	if lines := readLines(t, path); len(lines) != maxHistory {
+		t.Errorf("file holds %d entries after opening, want %d", len(lines), maxHistory)
+	}
+
+	for n := 0; n < 10; n++ {
+		h.add(fmt.Sprintf("new %d", n))
+	}
+	if err := h.close(); err != nil {
+		t.Fatal(err)
+	}
+	lines := readLines(t, path)
+	if len(lines) != maxHistory || lines[0] != "old 510" || lines[len(lines)-1] != "new 9" {
+		t.Errorf("file holds %d entries from %q to %q, want %d from %q to %q", len(lines), lines[0], lines[len(lines)-1], maxHistory, "old 510", "new 9")
+	}
+}
+
+func TestHistorySearch(t *testing.T) {
+	h := &history{entries: []string{"var a = 1;", "print a;", "var b = 2;"}}
+	tests := []struct {
+		query string
+		from  int
+		want  int
+	}{
+		{"var", 3, 2},
+		{"var", 2, 0},
+		{"print", 3, 1},
+		{"missing", 3, -1},
+		{"var", 0, -1},
+	}
+	for _, test := range tests {
+		if got := h.search(test.query, test.from); got != test.want {
+			t.Errorf("search(%q, %d) = %d, want %d", test.query, test.from, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package lineedit
+
+import "errors"
+
+type termState struct{}
+
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+func makeRaw(fd uintptr) (*termState, error) {
+	return nil, errors.New("lineedit: raw mode is not supported on this platform")
+}
+
+func setState(fd uintptr, state *termState) error {
+	return nil
+}
@@ -0,0 +1,55 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package lineedit
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+type termState struct {
+	termios syscall.Termios
+}
+
+func getState(fd uintptr) (*termState, error) {
+	var state termState
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlGetTermios, uintptr(unsafe.Pointer(&state.termios)))
+	if errno != 0 {
+		return nil, errno
+	}
+	return &state, nil
+}
+
+func setState(fd uintptr, state *termState) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlSetTermios, uintptr(unsafe.Pointer(&state.termios)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func isTerminal(fd uintptr) bool {
+	_, err := getState(fd)
+	return err == nil
+}
+
+// makeRaw switches the terminal to byte-at-a-time input without echo or signal keys,
+// returning the state to restore afterwards. Output processing is left on so "\n" still
+// starts a new line
+func makeRaw(fd uintptr) (*termState, error) {
+	old, err := getState(fd)
+	if err != nil {
+		return nil, err
+	}
+	raw := *old
+	raw.termios.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.termios.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.termios.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.termios.Cflag |= syscall.CS8
+	raw.termios.Cc[syscall.VMIN] = 1
+	raw.termios.Cc[syscall.VTIME] = 0
+	if err := setState(fd, &raw); err != nil {
+		return nil, err
+	}
+	return old, nil
+}
@@ -0,0 +1,109 @@
+package loxtest
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/reilandeubank/golox/pkg/interpreter"
+)
+
+func defineAssertions(i *interpreter.Interpreter) {
+	i.Define("assert", &assert{})
+	i.Define("assertEqual", &assertEqual{})
+	i.Define("assertThrows", &assertThrows{})
+}
+
+// failure is the error an assertion returns. The interpreter fills in the line of the call
+func failure(format string, args ...interface{}) error {
+	return &interpreter.RuntimeError{Message: fmt.Sprintf(format, args...)}
+}
+
+// show renders a value for a failure message, quoting strings so "1" and 1 differ
+func show(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return interpreter.Stringify(value)
+}
+
+type assert struct{}
+
+func (a *assert) Arity() (int, int) {
+	return 2, 2
+}
+
+// Call fails the test with the message unless the condition is truthy
+func (a *assert) Call(i *interpreter.Interpreter, arguments []interface{}) (interface{}, error) {
+	if !interpreter.IsTruthy(arguments[0]) {
+		return nil, failure("assert failed: %s", interpreter.Stringify(arguments[1]))
+	}
+	return nil, nil
+}
+
+func (a assert) String() string {
+	return "<native fn>"
+}
+
+type assertEqual struct{}
+
+func (a *assertEqual) Arity() (int, int) {
+	return 2, 2
+}
+
+// Call fails the test unless its arguments are equal. Lists are compared element by
+// element, unlike with ==, which compares them by identity
+func (a *assertEqual) Call(i *interpreter.Interpreter, arguments []interface{}) (interface{}, error) {
+	if !equal(arguments[0], arguments[1]) {
+		return nil, failure("assertEqual failed: %s != %s", show(arguments[0]), show(arguments[1]))
+	}
+	return nil, nil
+}
+
+func (a assertEqual) String() string {
+	return "<native fn>"
+}
+
+func equal(a, b interface{}) bool {
+	listA, okA := a.(*interpreter.LoxList)
+	listB, okB := b.(*interpreter.LoxList)
+	if !okA || !okB {
+		return interpreter.IsEqual(a, b)
+	}
+	if len(listA.Elements) != len(listB.Elements) {
+		return false
+	}
+	for j := range listA.Elements {
+		if !equal(listA.Elements[j], listB.Elements[j]) {
+			return false
+		}
+	}
+	return true
+}
+
+type assertThrows struct{}
+
+func (a *assertThrows) Arity() (int, int) {
+	return 1, 1
+}
+
+// Call calls its argument, a function taking no arguments, and fails the test unless it
+// stops with a runtime error
+func (a *assertThrows) Call(i *interpreter.Interpreter, arguments []interface{}) (interface{}, error) {
+	function, ok := arguments[0].(interpreter.LoxCallable)
+	if !ok || !interpreter.Accepts(function, 0) {
+		return nil, failure("assertThrows needs a function that takes no arguments")
+	}
+	_, err := function.Call(i, nil)
+	if errors.Is(err, interpreter.ErrBudgetExceeded) || errors.Is(err, interpreter.ErrHalted) {
+		return nil, err
+	}
+	var runtimeErr *interpreter.RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		return nil, failure("assertThrows failed: %s did not throw", interpreter.Stringify(function))
+	}
+	return nil, nil
+}
+
+func (a assertThrows) String() string {
+	return "<native fn>"
+}
@@ -0,0 +1,98 @@
+// Package loxtest runs tests written in Lox. A test file's name ends in _test.lox, and
+// each of its top-level functions named test_* is a test. Tests check their results
+// with the assert, assertEqual and assertThrows natives.
+package loxtest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/reilandeubank/golox/pkg/interpreter"
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+// Suffix ends the name of every test file
+const Suffix = "_test.lox"
+
+// ErrSyntax is returned for a test file that doesn't parse. The errors themselves are
+// reported to os.Stderr like any other parse error
+var ErrSyntax = errors.New("test file has syntax errors")
+
+// Result is the outcome of one test
+type Result struct {
+	Name    string
+	Failed  bool
+	Line    int    // where a failed test stopped, or 0 if unknown
+	Message string // why it failed
+	Output  string // what the test printed
+	Elapsed time.Duration
+}
+
+// tests returns the test functions declared at the top level of statements whose names
+// match filter, in order. A nil filter matches every test
+func tests(statements []parser.Stmt, filter *regexp.Regexp) []parser.FunctionStmt {
+	var functions []parser.FunctionStmt
+	for _, stmt := range statements {
+		function, ok := stmt.(parser.FunctionStmt)
+		if !ok || !strings.HasPrefix(function.Name.Lexeme, "test_") {
+			continue
+		}
+		if filter == nil || filter.MatchString(function.Name.Lexeme) {
+			functions = append(functions, function)
+		}
+	}
+	return functions
+}
+
+// Run runs the matching tests in source. Each test gets a fresh interpreter, which runs
+// the file's top-level code and then calls the test function
+func Run(source string, filter *regexp.Regexp, opts ...interpreter.Option) ([]Result, error) {
+	thisScanner := scanner.NewScanner(source)
+	thisParser := parser.NewParser(thisScanner.ScanTokens())
+	statements, err := thisParser.Parse()
+	if err != nil || len(thisScanner.Errors) > 0 || len(thisParser.Errors) > 0 {
+		return nil, ErrSyntax
+	}
+
+	var results []Result
+	for _, test := range tests(statements, filter) {
+		results = append(results, runTest(statements, test, opts))
+	}
+	return results, nil
+}
+
+func runTest(statements []parser.Stmt, test parser.FunctionStmt, opts []interpreter.Option) Result {
+	result := Result{Name: test.Name.Lexeme}
+	var output bytes.Buffer
+	// Runtime errors are reported through the result instead
+	opts = append([]interpreter.Option{interpreter.WithStdout(&output), interpreter.WithStderr(io.Discard)}, opts...)
+	i := interpreter.NewInterpreter(opts...)
+	defineAssertions(&i)
+
+	// Calling the test as one more top-level statement keeps it within any budget in opts
+	call := parser.ExprStmt{Expression: parser.Call{Callee: parser.Variable{Name: test.Name}, Paren: test.Name}}
+	program := append(append([]parser.Stmt{}, statements...), call)
+
+	start := time.Now()
+	err := i.Interpret(program)
+	result.Elapsed = time.Since(start)
+	result.Output = output.String()
+
+	if err != nil {
+		result.Failed = true
+		result.Message = err.Error()
+		var runtimeErr *interpreter.RuntimeError
+		var budgetErr *interpreter.BudgetError
+		if errors.As(err, &runtimeErr) {
+			result.Line, result.Message = runtimeErr.Token.Line, runtimeErr.Message
+		} else if errors.As(err, &budgetErr) {
+			result.Line, result.Message = budgetErr.Line, "Execution stopped: "+budgetErr.Reason
+		}
+	}
+	return result
+}
@@ -0,0 +1,135 @@
+package loxtest
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/reilandeubank/golox/pkg/interpreter"
+)
+
+const source = `var total = 0;
+
+fun test_passes() {
+    print "running";
+    assert(1 < 2, "one is less than two");
+    assertEqual(total, 0);
+    fun throws() { return nil + 1; }
+    assertThrows(throws);
+}
+
+fun test_assert() {
+    assert(false, "should fail");
+}
+
+fun test_assert_equal() {
+    total = total + 1;
+    assertEqual(total, "1");
+}
+
+fun test_assert_throws() {
+    fun returns() { return 1; }
+    assertThrows(returns);
+}
+
+fun test_assert_throws_arguments() {
+    fun identity(x) { return x; }
+    assertThrows(identity);
+}
+
+fun test_runtime_error() {
+    var x = nil;
+    print -x;
+}
+
+fun helper() {
+    assert(false, "not a test");
+}
+`
+
+func TestRun(t *testing.T) {
+	results, err := Run(source, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Result{
+		{Name: "test_passes", Output: "running\n"},
+		{Name: "test_assert", Failed: true, Line: 12, Message: "assert failed: should fail"},
+		{Name: "test_assert_equal", Failed: true, Line: 17, Message: `assertEqual failed: 1 != "1"`},
+		{Name: "test_assert_throws", Failed: true, Line: 22, Message: "assertThrows failed: <fn returns> did not throw"},
+		{Name: "test_assert_throws_arguments", Failed: true, Line: 27, Message: "assertThrows needs a function that takes no arguments"},
+		{Name: "test_runtime_error", Failed: true, Line: 32, Message: "Operator must be a number"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d: %+v", len(results), len(want), results)
+	}
+	for j, result := range results {
+		result.Elapsed = 0
+		if result != want[j] {
+			t.Errorf("got %+v\nwant %+v", result, want[j])
+		}
+	}
+}
+
+func TestRunCompare(t *testing.T) {
+	const compare = `fun test_functions() {
+    fun f() {}
+    fun g() {}
+    assertEqual(f, f);
+    assert(f != g, "distinct functions differ");
+    assertThrows(f);
+}
+
+fun list(...elements) { return elements; }
+
+fun test_lists() {
+    assertEqual(list(1, list(2, "a")), list(1, list(2, "a")));
+    assertEqual(list(1), list(1, 2));
+}
+`
+	results, err := Run(compare, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Result{
+		{Name: "test_functions", Failed: true, Line: 6, Message: "assertThrows failed: <fn f> did not throw"},
+		{Name: "test_lists", Failed: true, Line: 13, Message: "assertEqual failed: [1] != [1, 2]"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d: %+v", len(results), len(want), results)
+	}
+	for j, result := range results {
+		result.Elapsed = 0
+		if result != want[j] {
+			t.Errorf("got %+v\nwant %+v", result, want[j])
+		}
+	}
+}
+
+func TestRunFilter(t *testing.T) {
+	results, err := Run(source, regexp.MustCompile("equal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each test runs in a fresh interpreter, so total starts from 0 again
+	if len(results) != 1 || results[0].Name != "test_assert_equal" || results[0].Message != `assertEqual failed: 1 != "1"` {
+		t.Errorf("got %+v, want only test_assert_equal", results)
+	}
+}
+
+func TestRunBudget(t *testing.T) {
+	results, err := Run("fun test_forever() {\n    while (true) {}\n}\n", nil, interpreter.WithStepLimit(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !results[0].Failed || results[0].Line != 2 || results[0].Message != "Execution stopped: step limit of 100 exceeded" {
+		t.Errorf("got %+v, want the step limit to fail the test at line 2", results)
+	}
+}
+
+func TestRunSyntaxError(t *testing.T) {
+	if _, err := Run("fun test_broken( {", nil); err != ErrSyntax {
+		t.Errorf("got %v, want ErrSyntax", err)
+	}
+}
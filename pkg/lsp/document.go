@@ -0,0 +1,304 @@
+package lsp
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/reilandeubank/golox/pkg/interpreter"
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/resolve"
+	"github.com/reilandeubank/golox/pkg/scanner"
+	"github.com/reilandeubank/golox/pkg/vet"
+)
+
+// natives and constants are the built-in globals, offered wherever a name is expected
+var (
+	natives   = interpreter.Natives()
+	constants = interpreter.Constants()
+)
+
+// document is an open file and everything the server has worked out about it
+type document struct {
+	uri         string
+	lines       []string
+	tokens      []scanner.Token
+	diagnostics []Diagnostic
+	symbols     []DocumentSymbol
+
+	bindings   []*resolve.Binding // declared by the program
+	references map[resolve.Key]*resolve.Binding
+}
+
+func analyze(uri string, text string) *document {
+	d := &document{
+		uri:   uri,
+		lines: strings.Split(text, "\n"),
+	}
+
+	// Errors are reported as diagnostics rather than written out
+	thisScanner := scanner.NewScanner(text)
+	thisScanner.ErrorOutput = io.Discard
+	d.tokens = thisScanner.ScanTokens()
+	for _, scanErr := range thisScanner.Errors {
+		start := d.position(scanErr.Line, scanErr.Column)
+		end := Position{Line: start.Line, Character: start.Character + 1}
+		d.diagnostics = append(d.diagnostics, Diagnostic{Range: Range{start, end}, Severity: severityError, Source: "golox", Message: scanErr.Message})
+	}
+
+	thisParser := parser.NewParser(d.tokens)
+	thisParser.ErrorOutput = io.Discard
+	statements, err := thisParser.Parse()
+	for _, syntaxErr := range thisParser.Errors {
+		d.diagnostics = append(d.diagnostics, Diagnostic{Range: d.tokenRange(syntaxErr.Token), Severity: severityError, Source: "golox", Message: syntaxErr.Message})
+	}
+	if err != nil || len(thisScanner.Errors) > 0 || len(thisParser.Errors) > 0 {
+		return d
+	}
+
+	resolution := resolve.Resolve(statements)
+	d.bindings = resolution.Bindings
+	d.references = resolution.References
+	d.symbols = d.documentSymbols(nil)
+
+	findings, err := vet.Check(text)
+	if err == nil {
+		for _, finding := range findings {
+			d.diagnostics = append(d.diagnostics, d.findingDiagnostic(finding))
+		}
+	}
+	return d
+}
+
+func (d *document) findingDiagnostic(finding vet.Finding) Diagnostic {
+	severity := severityError
+	switch finding.Severity {
+	case vet.Warning:
+		severity = severityWarning
+	case vet.Info:
+		severity = severityInformation
+	}
+
+	var r Range
+	if token, ok := d.tokenAt(finding.Line, finding.Column); ok && finding.Column != 0 {
+		r = d.tokenRange(token)
+	} else {
+		// Only the line is known, so mark all of it
+		line := finding.Line - 1
+		r = Range{Position{line, 0}, d.position(finding.Line, len(d.line(finding.Line))+1)}
+	}
+	return Diagnostic{Range: r, Severity: severity, Code: finding.Rule, Source: "golox vet", Message: finding.Message}
+}
+
+func (d *document) line(line int) string {
+	if line < 1 || line > len(d.lines) {
+		return ""
+	}
+	return d.lines[line-1]
+}
+
+// position converts a 1-based line and byte column to an LSP position, which counts
+// UTF-16 code units from 0
+func (d *document) position(line int, column int) Position {
+	text := d.line(line)
+	prefix := text[:min(max(column-1, 0), len(text))]
+	return Position{Line: max(line-1, 0), Character: len(utf16.Encode([]rune(prefix)))}
+}
+
+func (d *document) tokenRange(t scanner.Token) Range {
+	start := d.position(t.Line, t.Column)
+	if t.Type == scanner.EOF {
+		return Range{start, start}
+	}
+	return Range{start, d.position(t.Line, t.Column+len(t.Lexeme))}
+}
+
+func (d *document) tokenAt(line int, column int) (scanner.Token, bool) {
+	for _, token := range d.tokens {
+		if token.Line == line && token.Column == column {
+			return token, true
+		}
+	}
+	return scanner.Token{}, false
+}
+
+// identifierAt finds the identifier token under an LSP position
+func (d *document) identifierAt(pos Position) (scanner.Token, bool) {
+	for _, token := range d.tokens {
+		if token.Type != scanner.IDENTIFIER || token.Line-1 != pos.Line {
+			continue
+		}
+		r := d.tokenRange(token)
+		if r.Start.Character <= pos.Character && pos.Character <= r.End.Character {
+			return token, true
+		}
+	}
+	return scanner.Token{}, false
+}
+
+// declarationAt returns the binding the program declares that the identifier under pos
+// names
+func (d *document) declarationAt(pos Position) (*resolve.Binding, scanner.Token, bool) {
+	token, ok := d.identifierAt(pos)
+	if !ok {
+		return nil, token, false
+	}
+	b, ok := d.references[resolve.KeyOf(token)]
+	if !ok || b.Kind == resolve.Native {
+		return nil, token, false
+	}
+	return b, token, true
+}
+
+// documentSymbols lists the variables and functions declared directly in owner's body,
+// or outside any function when owner is nil, each function with its own as children
+func (d *document) documentSymbols(owner *resolve.Binding) []DocumentSymbol {
+	var declared []*resolve.Binding
+	for _, b := range d.bindings {
+		if b.Owner == owner && (b.Kind == resolve.Variable || b.Kind == resolve.Function) {
+			declared = append(declared, b)
+		}
+	}
+	sort.Slice(declared, func(a, b int) bool {
+		if declared[a].Name.Line != declared[b].Name.Line {
+			return declared[a].Name.Line < declared[b].Name.Line
+		}
+		return declared[a].Name.Column < declared[b].Name.Column
+	})
+
+	var symbols []DocumentSymbol
+	for _, b := range declared {
+		symbol := DocumentSymbol{
+			Name:           b.Name.Lexeme,
+			Kind:           symbolVariable,
+			Range:          d.extent(b.Name, false),
+			SelectionRange: d.tokenRange(b.Name),
+		}
+		if b.Kind == resolve.Function {
+			symbol.Detail = signature(b.Function)
+			symbol.Kind = symbolFunction
+			symbol.Range = d.extent(b.Name, true)
+			symbol.Children = d.documentSymbols(b)
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// extent returns the range of a declaration from the keyword before its name to the
+// end of its body or initializer
+func (d *document) extent(name scanner.Token, isFunction bool) Range {
+	index := -1
+	for j, token := range d.tokens {
+		if resolve.KeyOf(token) == resolve.KeyOf(name) {
+			index = j
+			break
+		}
+	}
+	if index < 0 {
+		return d.tokenRange(name)
+	}
+	start := name
+	if index > 0 {
+		start = d.tokens[index-1]
+	}
+
+	end := name
+	depth := 0
+	for _, token := range d.tokens[index:] {
+		end = token
+		if token.Type == scanner.LEFT_BRACE {
+			depth++
+		} else if token.Type == scanner.RIGHT_BRACE {
+			depth--
+			if isFunction && depth == 0 {
+				break
+			}
+		} else if !isFunction && token.Type == scanner.SEMICOLON && depth == 0 {
+			break
+		}
+	}
+	return Range{d.tokenRange(start).Start, d.tokenRange(end).End}
+}
+
+func signature(f *parser.FunctionStmt) string {
+	params := make([]string, len(f.Params))
+	for j, param := range f.Params {
+		params[j] = param.Lexeme
+	}
+	if f.Rest {
+		params[len(params)-1] = "..." + params[len(params)-1]
+	}
+	return "fun " + f.Name.Lexeme + "(" + strings.Join(params, ", ") + ")"
+}
+
+func (d *document) hover(pos Position) *Hover {
+	token, ok := d.identifierAt(pos)
+	if !ok {
+		return nil
+	}
+	r := d.tokenRange(token)
+
+	var text string
+	if b, ok := d.references[resolve.KeyOf(token)]; ok && b.Kind != resolve.Native {
+		switch b.Kind {
+		case resolve.Function:
+			text = signature(b.Function)
+		case resolve.Parameter:
+			text = "(parameter) " + b.Name.Lexeme + " of " + b.Owner.Name.Lexeme
+		default:
+			text = fmt.Sprintf("var %s (declared at line %d)", b.Name.Lexeme, b.Name.Line)
+		}
+	} else if native, ok := natives[token.Lexeme]; ok {
+		text = fmt.Sprintf("native fn %s (%s arguments)", token.Lexeme, interpreter.ArityString(native))
+	} else if value, ok := constants[token.Lexeme]; ok {
+		text = fmt.Sprintf("const %s = %s", token.Lexeme, interpreter.Stringify(value))
+	} else {
+		return nil
+	}
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: "```lox\n" + text + "\n```"}, Range: &r}
+}
+
+func (d *document) completions() []CompletionItem {
+	var items []CompletionItem
+	for _, keyword := range scanner.Keywords() {
+		items = append(items, CompletionItem{Label: keyword, Kind: completionKeyword})
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for name := range natives {
+		names = append(names, name)
+		seen[name] = true
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		items = append(items, CompletionItem{Label: name, Kind: completionFunction, Detail: "native fn"})
+	}
+	names = nil
+	for name := range constants {
+		names = append(names, name)
+		seen[name] = true
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		items = append(items, CompletionItem{Label: name, Kind: completionConstant, Detail: "const"})
+	}
+
+	declared := append([]*resolve.Binding(nil), d.bindings...)
+	sort.SliceStable(declared, func(a, b int) bool { return declared[a].Name.Lexeme < declared[b].Name.Lexeme })
+	for _, b := range declared {
+		if seen[b.Name.Lexeme] {
+			continue
+		}
+		seen[b.Name.Lexeme] = true
+		if b.Function != nil {
+			items = append(items, CompletionItem{Label: b.Name.Lexeme, Kind: completionFunction, Detail: signature(b.Function)})
+		} else {
+			items = append(items, CompletionItem{Label: b.Name.Lexeme, Kind: completionVariable})
+		}
+	}
+	return items
+}
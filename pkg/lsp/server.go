@@ -0,0 +1,192 @@
+// Package lsp implements a Language Server Protocol server for Lox, giving editors
+// diagnostics, document symbols, go-to-definition, hover and completion.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/reilandeubank/golox/pkg/transport"
+)
+
+// ErrExitWithoutShutdown is returned by Run when the client sends exit before shutdown
+var ErrExitWithoutShutdown = errors.New("exit notification received before shutdown")
+
+type Server struct {
+	in        *bufio.Reader
+	out       *transport.Writer
+	documents map[string]*document
+	shutdown  bool
+}
+
+// NewServer returns a server reading requests from in and writing responses to out
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:        bufio.NewReader(in),
+		out:       transport.NewWriter(out),
+		documents: make(map[string]*document),
+	}
+}
+
+// Run serves requests until the client sends exit or closes the input
+func (s *Server) Run() error {
+	for {
+		body, err := transport.ReadMessage(s.in)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.reply(nil, nil, &responseError{Code: codeParseError, Message: err.Error()})
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+
+		result, rpcErr := s.handle(req)
+		if req.ID != nil {
+			s.reply(req.ID, result, rpcErr)
+		}
+	}
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, rpcErr *responseError) {
+	if id == nil {
+		null := json.RawMessage("null")
+		id = &null
+	}
+	body, _ := json.Marshal(response{JSONRPC: "2.0", ID: id, Result: result, Error: rpcErr})
+	s.out.WriteMessage(body)
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	body, _ := json.Marshal(notification{JSONRPC: "2.0", Method: method, Params: params})
+	s.out.WriteMessage(body)
+}
+
+func (s *Server) handle(req request) (interface{}, *responseError) {
+	switch req.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":       1, // the full text is sent on every change
+				"documentSymbolProvider": true,
+				"definitionProvider":     true,
+				"hoverProvider":          true,
+				"completionProvider":     map[string]interface{}{},
+			},
+			"serverInfo": map[string]string{"name": "golox"},
+		}, nil
+	case "initialized", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.open(params.TextDocument.URI, params.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			s.open(params.TextDocument.URI, params.ContentChanges[n-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		delete(s.documents, params.TextDocument.URI)
+		s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+		return nil, nil
+
+	case "textDocument/documentSymbol":
+		var params struct {
+			TextDocument textDocumentIdentifier `json:"textDocument"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		d, ok := s.documents[params.TextDocument.URI]
+		if !ok || d.symbols == nil {
+			return []DocumentSymbol{}, nil
+		}
+		return d.symbols, nil
+	case "textDocument/definition":
+		d, pos, rpcErr := s.position(req)
+		if rpcErr != nil || d == nil {
+			return nil, rpcErr
+		}
+		decl, _, ok := d.declarationAt(pos)
+		if !ok {
+			return nil, nil
+		}
+		return Location{URI: d.uri, Range: d.tokenRange(decl.Name)}, nil
+	case "textDocument/hover":
+		d, pos, rpcErr := s.position(req)
+		if rpcErr != nil || d == nil {
+			return nil, rpcErr
+		}
+		if hover := d.hover(pos); hover != nil {
+			return hover, nil
+		}
+		return nil, nil
+	case "textDocument/completion":
+		d, _, rpcErr := s.position(req)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		if d == nil {
+			d = analyze("", "")
+		}
+		return d.completions(), nil
+	}
+
+	if req.ID == nil {
+		// Unknown notifications are ignored
+		return nil, nil
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+// open analyzes the latest text of a document and publishes its diagnostics
+func (s *Server) open(uri string, text string) {
+	d := analyze(uri, text)
+	s.documents[uri] = d
+	diagnostics := d.diagnostics
+	if diagnostics == nil {
+		diagnostics = []Diagnostic{}
+	}
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+}
+
+// position decodes the parameters of a request about a position in a document. The
+// document is nil if it isn't open
+func (s *Server) position(req request) (*document, Position, *responseError) {
+	var params textDocumentPositionParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return nil, Position{}, invalidParams(err)
+	}
+	return s.documents[params.TextDocument.URI], params.Position, nil
+}
+
+func invalidParams(err error) *responseError {
+	return &responseError{Code: codeInvalidParams, Message: err.Error()}
+}
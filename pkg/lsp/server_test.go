@@ -0,0 +1,302 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/reilandeubank/golox/pkg/transport"
+)
+
+// client drives a server over pipes the way an editor would
+type client struct {
+	t      *testing.T
+	out    *transport.Writer
+	in     *bufio.Reader
+	nextID int
+	done   chan error
+}
+
+func newClient(t *testing.T) *client {
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	c := &client{t: t, out: transport.NewWriter(clientOut), in: bufio.NewReader(clientIn), done: make(chan error, 1)}
+	go func() {
+		err := NewServer(serverIn, serverOut).Run()
+		serverOut.Close()
+		c.done <- err
+	}()
+	return c
+}
+
+type message struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+func (c *client) send(id *int, method string, params interface{}) {
+	body, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if id == nil {
+		body, _ = json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+	}
+	if err := c.out.WriteMessage(body); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *client) read() message {
+	body, err := transport.ReadMessage(c.in)
+	if err != nil {
+		c.t.Fatalf("reading from server: %v", err)
+	}
+	var m message
+	if err := json.Unmarshal(body, &m); err != nil {
+		c.t.Fatalf("decoding %s: %v", body, err)
+	}
+	return m
+}
+
+// call sends a request and decodes the result of its response into result
+func (c *client) call(method string, params interface{}, result interface{}) *responseError {
+	c.nextID++
+	id := c.nextID
+	c.send(&id, method, params)
+	for {
+		m := c.read()
+		if m.ID == nil || *m.ID != id {
+			continue
+		}
+		if m.Error == nil && result != nil {
+			if err := json.Unmarshal(m.Result, result); err != nil {
+				c.t.Fatalf("decoding result of %s: %v", method, err)
+			}
+		}
+		return m.Error
+	}
+}
+
+// diagnostics sends a notification and waits for the diagnostics it publishes
+func (c *client) diagnostics(method string, params interface{}) []Diagnostic {
+	c.send(nil, method, params)
+	for {
+		m := c.read()
+		if m.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var published publishDiagnosticsParams
+		if err := json.Unmarshal(m.Params, &published); err != nil {
+			c.t.Fatal(err)
+		}
+		return published.Diagnostics
+	}
+}
+
+func (c *client) close() {
+	c.call("shutdown", nil, nil)
+	c.send(nil, "exit", nil)
+	if err := <-c.done; err != nil {
+		c.t.Fatalf("server exited with %v", err)
+	}
+}
+
+const uri = "file:///test.lox"
+
+const source = `var greeting = "hi";
+
+fun add(a, b) {
+    var sum = a + b;
+    return sum;
+}
+
+print add(1, 2);
+print greeting;
+`
+
+func at(line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+		"position":     Position{Line: line, Character: character},
+	}
+}
+
+func open(c *client, text string) []Diagnostic {
+	return c.diagnostics("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "languageId": "lox", "version": 1, "text": text},
+	})
+}
+
+func TestInitialize(t *testing.T) {
+	c := newClient(t)
+	var result struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+	if err := c.call("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}}, &result); err != nil {
+		t.Fatal(err)
+	}
+	for _, capability := range []string{"documentSymbolProvider", "definitionProvider", "hoverProvider", "completionProvider"} {
+		if _, ok := result.Capabilities[capability]; !ok {
+			t.Errorf("missing capability %s", capability)
+		}
+	}
+	c.send(nil, "initialized", map[string]interface{}{})
+	c.close()
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newClient(t)
+	if diagnostics := open(c, source); len(diagnostics) != 0 {
+		t.Errorf("clean source has diagnostics %+v", diagnostics)
+	}
+
+	diagnostics := c.diagnostics("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 2},
+		"contentChanges": []map[string]string{{"text": "var x = 1;\nprint x +;\n"}},
+	})
+	if len(diagnostics) != 1 {
+		t.Fatalf("got %d diagnostics, want 1: %+v", len(diagnostics), diagnostics)
+	}
+	if d := diagnostics[0]; d.Severity != severityError || d.Range.Start.Line != 1 || !strings.Contains(d.Message, "expect expression") {
+		t.Errorf("unexpected syntax diagnostic %+v", d)
+	}
+
+	diagnostics = c.diagnostics("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 3},
+		"contentChanges": []map[string]string{{"text": "{ var unused = 1; }\nprint \"open;\n"}},
+	})
+	if len(diagnostics) == 0 || !strings.Contains(diagnostics[0].Message, "Unterminated string") {
+		t.Errorf("want an unterminated string diagnostic, got %+v", diagnostics)
+	}
+
+	diagnostics = c.diagnostics("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 4},
+		"contentChanges": []map[string]string{{"text": "{ var unused = 1; }\n"}},
+	})
+	if len(diagnostics) != 1 || diagnostics[0].Code != "unused-variable" || diagnostics[0].Severity != severityWarning {
+		t.Errorf("want an unused-variable warning, got %+v", diagnostics)
+	}
+	want := Range{Position{0, 6}, Position{0, 12}}
+	if len(diagnostics) == 1 && diagnostics[0].Range != want {
+		t.Errorf("got range %+v, want %+v", diagnostics[0].Range, want)
+	}
+	c.close()
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	c := newClient(t)
+	open(c, source)
+	var symbols []DocumentSymbol
+	if err := c.call("textDocument/documentSymbol", map[string]interface{}{"textDocument": map[string]string{"uri": uri}}, &symbols); err != nil {
+		t.Fatal(err)
+	}
+	if len(symbols) != 2 {
+		t.Fatalf("got %d symbols, want 2: %+v", len(symbols), symbols)
+	}
+	if symbols[0].Name != "greeting" || symbols[0].Kind != symbolVariable {
+		t.Errorf("first symbol is %+v", symbols[0])
+	}
+	add := symbols[1]
+	if add.Name != "add" || add.Kind != symbolFunction || add.Detail != "fun add(a, b)" {
+		t.Errorf("second symbol is %+v", add)
+	}
+	if add.Range.Start.Line != 2 || add.Range.End.Line != 5 {
+		t.Errorf("add spans %+v, want lines 2 to 5", add.Range)
+	}
+	if len(add.Children) != 1 || add.Children[0].Name != "sum" {
+		t.Errorf("add has children %+v", add.Children)
+	}
+	c.close()
+}
+
+func TestDefinition(t *testing.T) {
+	c := newClient(t)
+	open(c, source)
+
+	tests := []struct {
+		line, character int
+		want            Range
+	}{
+		{7, 7, Range{Position{2, 4}, Position{2, 7}}},   // add(1, 2)
+		{8, 10, Range{Position{0, 4}, Position{0, 12}}}, // greeting
+		{3, 14, Range{Position{2, 8}, Position{2, 9}}},  // a in a + b
+		{4, 12, Range{Position{3, 8}, Position{3, 11}}}, // return sum
+	}
+	for _, test := range tests {
+		var location Location
+		if err := c.call("textDocument/definition", at(test.line, test.character), &location); err != nil {
+			t.Fatal(err)
+		}
+		if location.URI != uri || location.Range != test.want {
+			t.Errorf("definition at %d:%d is %+v, want %+v", test.line, test.character, location, test.want)
+		}
+	}
+
+	var location *Location
+	if err := c.call("textDocument/definition", at(7, 0), &location); err != nil {
+		t.Fatal(err)
+	}
+	if location != nil {
+		t.Errorf("definition of a keyword is %+v, want null", location)
+	}
+	c.close()
+}
+
+func TestHover(t *testing.T) {
+	c := newClient(t)
+	open(c, "fun add(a, b) { return a + b; }\nprint add(1, 2);\nprint clock();\n")
+
+	tests := []struct {
+		line, character int
+		want            string
+	}{
+		{1, 7, "fun add(a, b)"},
+		{0, 23, "(parameter) a of add"},
+		{2, 8, "native fn clock (0 arguments)"},
+	}
+	for _, test := range tests {
+		var hover Hover
+		if err := c.call("textDocument/hover", at(test.line, test.character), &hover); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(hover.Contents.Value, test.want) {
+			t.Errorf("hover at %d:%d is %q, want %q", test.line, test.character, hover.Contents.Value, test.want)
+		}
+	}
+	c.close()
+}
+
+func TestCompletion(t *testing.T) {
+	c := newClient(t)
+	open(c, source)
+	var items []CompletionItem
+	if err := c.call("textDocument/completion", at(9, 0), &items); err != nil {
+		t.Fatal(err)
+	}
+	labels := make(map[string]int)
+	for _, item := range items {
+		labels[item.Label] = item.Kind
+	}
+	want := map[string]int{"while": completionKeyword, "clock": completionFunction, "add": completionFunction, "greeting": completionVariable}
+	for label, kind := range want {
+		if labels[label] != kind {
+			t.Errorf("completion %q has kind %d, want %d", label, labels[label], kind)
+		}
+	}
+	c.close()
+}
+
+func TestUnknownMethod(t *testing.T) {
+	c := newClient(t)
+	err := c.call("workspace/symbol", map[string]string{"query": ""}, nil)
+	if err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("got error %+v, want method not found", err)
+	}
+	c.close()
+}
@@ -0,0 +1,26 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/reilandeubank/golox/internal/corpus"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+// BenchmarkParse measures parsing alone, from tokens scanned beforehand
+func BenchmarkParse(b *testing.B) {
+	for _, w := range corpus.Benchmarks(b) {
+		source := w.Source
+		thisScanner := scanner.NewScanner(source)
+		tokens := thisScanner.ScanTokens()
+		b.Run(w.Name, func(b *testing.B) {
+			b.SetBytes(int64(len(source)))
+			for n := 0; n < b.N; n++ {
+				thisParser := NewParser(tokens)
+				if _, err := thisParser.Parse(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
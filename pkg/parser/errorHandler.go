@@ -4,12 +4,26 @@ import (
 	"github.com/reilandeubank/golox/pkg/scanner"
 )
 
-func ParseError(t scanner.Token, message string) {
+// SyntaxError is an error found while parsing, as recorded in Parser.Errors
+type SyntaxError struct {
+	Token   scanner.Token
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return e.Message
+}
+
+// error reports message at token t to the parser's ErrorOutput and records it in p.Errors
+func (p *Parser) error(t scanner.Token, message string) *SyntaxError {
 	if t.Type == scanner.EOF {
-		scanner.Report(t.Line, " at end", message)
+		scanner.Report(p.ErrorOutput, t.Line, " at end", message)
 	} else {
-		scanner.Report(t.Line, " at '" + t.Lexeme + "'", message)
+		scanner.Report(p.ErrorOutput, t.Line, " at '" + t.Lexeme + "'", message)
 	}
+	err := &SyntaxError{Token: t, Message: message}
+	p.Errors = append(p.Errors, err)
+	return err
 }
 
 func (p *Parser) synchronize() {
@@ -18,8 +18,10 @@ type Expression interface {
 
 // Literal is a struct that implements the Expression interface
 type Literal struct {
-	Value interface{}
-	Type  scanner.TokenType
+	Value  interface{}
+	Type   scanner.TokenType
+	Line   int
+	Column int
 }
 
 // Accept() is a method that returns a string representation of the expression
@@ -116,4 +118,18 @@ type Call struct {
 // Accept() is a method that returns a string representation of the expression
 func (c Call) Accept(v ExprVisitor) (interface{}, error) {
 	return v.VisitCallExpr(c)
+}
+
+// Index
+
+// Index is a struct that implements the Expression interface
+type Index struct {
+	Object  Expression
+	Bracket scanner.Token // the closing ']', for reporting errors
+	Index   Expression
+}
+
+// Accept() is a method that returns a string representation of the expression
+func (i Index) Accept(v ExprVisitor) (interface{}, error) {
+	return v.VisitIndexExpr(i)
 }
\ No newline at end of file
@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"io"
+	"testing"
+
+	"github.com/reilandeubank/golox/internal/corpus"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+func FuzzParse(f *testing.F) {
+	corpus.Seeds(f,
+		"",
+		"return 1;",
+		"fun f(a, b) { return a + b; } print f(1, 2);",
+	)
+	f.Fuzz(func(t *testing.T, source string) {
+		thisScanner := scanner.NewScanner(source)
+		thisScanner.ErrorOutput = io.Discard
+		tokens := thisScanner.ScanTokens()
+
+		thisParser := NewParser(tokens)
+		thisParser.ErrorOutput = io.Discard
+		statements, err := thisParser.Parse()
+		if err != nil {
+			syntaxErr, ok := err.(*SyntaxError)
+			if !ok {
+				t.Fatalf("Parse returned %T, want *SyntaxError", err)
+			}
+			if n := len(thisParser.Errors); n == 0 || thisParser.Errors[n-1] != syntaxErr {
+				t.Fatal("Parse returned an error it didn't report")
+			}
+			return
+		}
+		for _, stmt := range statements {
+			ASTPrinter{}.PrintStmt(stmt)
+		}
+
+		exprParser := NewParser(tokens)
+		exprParser.ErrorOutput = io.Discard
+		exprParser.ParseExpression()
+	})
+}
@@ -3,7 +3,6 @@ package parser
 import (
 	"fmt"
 	"github.com/reilandeubank/golox/pkg/scanner"
-	"errors"
 )
 
 func (p *Parser) expr() (Expression, error) {
@@ -11,6 +10,10 @@ func (p *Parser) expr() (Expression, error) {
 }
 
 func (p *Parser) statement() (Stmt, error) {
+	if err := p.nest(); err != nil {
+		return ExprStmt{}, err
+	}
+	defer p.unnest()
 	if p.match(scanner.FOR) {
 		return p.forStatement()
 	}
@@ -149,6 +152,10 @@ func (p *Parser) varDeclaration() (Stmt, error) {
 
 func (p *Parser) returnStatement() (Stmt, error) {
 	keyword := p.previous()
+	if p.functionDepth == 0 {
+		message := "Can't return from top-level code."
+		return ReturnStmt{}, p.error(keyword, message)
+	}
 	var value Expression
 	if !p.check(scanner.SEMICOLON) {
 		var err error
@@ -200,12 +207,15 @@ func (p *Parser) function(kind string) (Stmt, error) {
 		return FunctionStmt{}, err
 	}
 	var parameters []scanner.Token
+	rest := false
 	if !p.check(scanner.RIGHT_PAREN) {
 		for {
 			if len(parameters) >= 255 {
 				message := "Cannot have more than 255 parameters."
-				ParseError(p.peek(), message)
+				p.error(p.peek(), message)
 			}
+			// A parameter written ...name collects the rest of the arguments
+			rest = p.match(scanner.ELLIPSIS)
 			param, err := p.consume(scanner.IDENTIFIER, "Expect parameter name.")
 			if err != nil {
 				return FunctionStmt{}, err
@@ -214,6 +224,10 @@ func (p *Parser) function(kind string) (Stmt, error) {
 			if !p.match(scanner.COMMA) {
 				break
 			}
+			if rest {
+				message := "Rest parameter must be last."
+				return FunctionStmt{}, p.error(p.previous(), message)
+			}
 		}
 	}
 	_, err = p.consume(scanner.RIGHT_PAREN, "Expect ')' after parameters.")
@@ -224,11 +238,13 @@ func (p *Parser) function(kind string) (Stmt, error) {
 	if err != nil {
 		return FunctionStmt{}, err
 	}
+	p.functionDepth++
 	body, err := p.block()
+	p.functionDepth--
 	if err != nil {
 		return FunctionStmt{}, err
 	}
-	return FunctionStmt{Name: name, Params: parameters, Body: body}, nil
+	return FunctionStmt{Name: name, Params: parameters, Rest: rest, Body: body}, nil
 }
 
 func (p *Parser) block() ([]Stmt, error) {
@@ -245,6 +261,10 @@ func (p *Parser) block() ([]Stmt, error) {
 }
 
 func (p *Parser) assignment() (Expression, error) {
+	if err := p.nest(); err != nil {
+		return Literal{Value: nil}, err
+	}
+	defer p.unnest()
 	expr, err := p.or()
 	if err != nil {
 		return Literal{Value: nil}, err
@@ -260,14 +280,16 @@ func (p *Parser) assignment() (Expression, error) {
 			return Assign{Name: name, Value: value}, nil
 		}
 		message := "Invalid assignment target"
-		ParseError(equals, message)
-		return Literal{Value: nil}, errors.New(message)
+		return Literal{Value: nil}, p.error(equals, message)
 	}
 	return expr, nil
 }
 
 func (p *Parser) or() (Expression, error) {
 	expr, err := p.and()
+	if err != nil {
+		return Literal{Value: nil}, err
+	}
 	for p.match(scanner.OR) {
 		operator := p.previous()
 		right, err := p.and()
@@ -276,11 +298,14 @@ func (p *Parser) or() (Expression, error) {
 		}
 		expr = Logical{Left: expr, Operator: operator, Right: right}
 	}
-	return expr, err
+	return expr, nil
 }
 
 func (p *Parser) and() (Expression, error) {
 	expr, err := p.equality()
+	if err != nil {
+		return Literal{Value: nil}, err
+	}
 	for p.match(scanner.AND) {
 		operator := p.previous()
 		right, err := p.equality()
@@ -289,7 +314,7 @@ func (p *Parser) and() (Expression, error) {
 		}
 		expr = Logical{Left: expr, Operator: operator, Right: right}
 	}
-	return expr, err
+	return expr, nil
 }
 
 func (p *Parser) declaration() (Stmt, error) {
@@ -314,50 +339,74 @@ func (p *Parser) declaration() (Stmt, error) {
 
 func (p *Parser) equality() (Expression, error) {
 	expr, err := p.comparison()
+	if err != nil {
+		return Literal{Value: nil}, err
+	}
 	for p.match(scanner.BANG_EQUAL, scanner.EQUAL_EQUAL) {
 		operator := p.previous()
-		var right Expression
-		right, err= p.comparison()
+		right, err := p.comparison()
+		if err != nil {
+			return Literal{Value: nil}, err
+		}
 		expr = Binary{Left: expr, Operator: operator, Right: right}
 	}
-	return expr, err
+	return expr, nil
 }
 
 func (p *Parser) comparison() (Expression, error) {
 	expr, err := p.term()
+	if err != nil {
+		return Literal{Value: nil}, err
+	}
 	for p.match(scanner.GREATER, scanner.GREATER_EQUAL, scanner.LESS, scanner.LESS_EQUAL) {
 		operator := p.previous()
-		var right Expression
-		right, err= p.term()
+		right, err := p.term()
+		if err != nil {
+			return Literal{Value: nil}, err
+		}
 		expr = Binary{Left: expr, Operator: operator, Right: right}
 	}
-	return expr, err
+	return expr, nil
 }
 
 func (p *Parser) term() (Expression, error) {
 	expr, err := p.factor()
+	if err != nil {
+		return Literal{Value: nil}, err
+	}
 	for p.match(scanner.MINUS, scanner.PLUS) {
 		operator := p.previous()
-		var right Expression
-		right, err = p.factor()
+		right, err := p.factor()
+		if err != nil {
+			return Literal{Value: nil}, err
+		}
 		expr = Binary{Left: expr, Operator: operator, Right: right}
 	}
-	return expr, err
+	return expr, nil
 }
 
 func (p *Parser) factor() (Expression, error) {
 	expr, err := p.unary()
+	if err != nil {
+		return Literal{Value: nil}, err
+	}
 	for p.match(scanner.SLASH, scanner.STAR) {
 		operator := p.previous()
-		var right Expression
-		right, err = p.unary()
+		right, err := p.unary()
+		if err != nil {
+			return Literal{Value: nil}, err
+		}
 		expr = Binary{Left: expr, Operator: operator, Right: right}
 	}
-	return expr, err
+	return expr, nil
 }
 
 func (p *Parser) unary() (Expression, error) {
 	if p.match(scanner.BANG, scanner.MINUS) {
+		if err := p.nest(); err != nil {
+			return Literal{Value: nil}, err
+		}
+		defer p.unnest()
 		operator := p.previous()
 		right, err := p.unary()
 		return Unary{Operator: operator, Right: right}, err
@@ -371,7 +420,7 @@ func (p *Parser) finishCall(callee Expression) (Expression, error) {
 		for {
 			if len(arguments) >= 255 {
 				message := "Cannot have more than 255 arguments."
-				ParseError(p.peek(), message)
+				p.error(p.peek(), message)
 			}
 			argument, err := p.expr()
 			if err != nil {
@@ -389,39 +438,54 @@ func (p *Parser) finishCall(callee Expression) (Expression, error) {
 
 func (p *Parser) call() (Expression, error) {
 	expr, err := p.primary()
+	if err != nil {
+		return Literal{Value: nil}, err
+	}
 	for {
 		if p.match(scanner.LEFT_PAREN) {
 			expr, err = p.finishCall(expr)
+			if err != nil {
+				return Literal{Value: nil}, err
+			}
+		} else if p.match(scanner.LEFT_BRACKET) {
+			index, err := p.expr()
+			if err != nil {
+				return Literal{Value: nil}, err
+			}
+			bracket, err := p.consume(scanner.RIGHT_BRACKET, "Expect ']' after index.")
+			if err != nil {
+				return Literal{Value: nil}, err
+			}
+			expr = Index{Object: expr, Bracket: bracket, Index: index}
 		} else {
 			break
 		}
 	}
-	return expr, err
+	return expr, nil
 }
 
 func (p *Parser) primary() (Expression, error) {
 	if p.match(scanner.FALSE) {
-		return Literal{Value: false, Type: scanner.FALSE}, nil
+		return Literal{Value: false, Type: scanner.FALSE, Line: p.previous().Line, Column: p.previous().Column}, nil
 	}
 	if p.match(scanner.TRUE) {
-		return Literal{Value: true, Type: scanner.TRUE}, nil
+		return Literal{Value: true, Type: scanner.TRUE, Line: p.previous().Line, Column: p.previous().Column}, nil
 	}
 	if p.match(scanner.NIL) {
-		return Literal{Value: nil, Type: scanner.NIL}, nil
+		return Literal{Value: nil, Type: scanner.NIL, Line: p.previous().Line, Column: p.previous().Column}, nil
 	}
 	if p.match(scanner.NUMBER, scanner.STRING) {
 		var prevValue interface{} = p.previous().Literal
 		var err error
 		switch prevValue.(type) {
 		case string:
-			return Literal{Value: prevValue, Type: scanner.STRING}, err
+			return Literal{Value: prevValue, Type: scanner.STRING, Line: p.previous().Line, Column: p.previous().Column}, err
 		case float64:
-			return Literal{Value: prevValue, Type: scanner.NUMBER}, err
+			return Literal{Value: prevValue, Type: scanner.NUMBER, Line: p.previous().Line, Column: p.previous().Column}, err
 		default:
 			// Handle other types or error
 			message := "unexpected literal type: " + fmt.Sprintf("%T", prevValue)
-			ParseError(p.peek(), message)
-			err = errors.New(message)
+			err = p.error(p.peek(), message)
 		}
 		return Literal{Value: nil, Type: scanner.NIL}, err
 	}
@@ -437,6 +501,5 @@ func (p *Parser) primary() (Expression, error) {
 		return Grouping{Expression: expr}, err
 	}
 	message := "expect expression"
-	ParseError(p.peek(), message)
-	return Literal{Value: nil}, errors.New(message)
+	return Literal{Value: nil}, p.error(p.peek(), message)
 }
\ No newline at end of file
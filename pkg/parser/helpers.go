@@ -2,7 +2,6 @@ package parser
 
 import (
 	"github.com/reilandeubank/golox/pkg/scanner"
-	"errors"
 )
 
 func (p *Parser) match(types ...scanner.TokenType) bool {
@@ -37,7 +36,26 @@ func (p *Parser) peek() scanner.Token {
 	return p.Tokens[p.Curr]
 }
 
+// nest enters a grammar rule that may recurse, failing once the input is nested too
+// deeply. Each successful call must be paired with a call to unnest
+func (p *Parser) nest() error {
+	if p.nesting >= maxNesting {
+		message := "Too much nesting."
+		return p.error(p.peek(), message)
+	}
+	p.nesting++
+	return nil
+}
+
+func (p *Parser) unnest() {
+	p.nesting--
+}
+
+// previous returns the token just consumed, or the first token if none has been
 func (p *Parser) previous() scanner.Token {
+	if p.Curr == 0 {
+		return p.Tokens[0]
+	}
 	return p.Tokens[p.Curr-1]
 }
 
@@ -45,6 +63,5 @@ func (p *Parser) consume(t scanner.TokenType, message string) (scanner.Token, er
 	if p.check(t) {
 		return p.advance(), nil
 	}
-	ParseError(p.peek(), message)
-	return scanner.NewToken(scanner.OTHER, "", nil, 0), errors.New(message)
+	return scanner.NewToken(scanner.OTHER, "", nil, 0), p.error(p.peek(), message)
 }
\ No newline at end of file
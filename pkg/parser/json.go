@@ -0,0 +1,386 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+// EncodeJSON serializes statements as a JSON array of nodes. Every node is an object whose
+// "kind" names its Go type, with the node's fields under their lowerCamelCase names
+func EncodeJSON(statements []Stmt) ([]byte, error) {
+	nodes := make([]interface{}, len(statements))
+	for j, stmt := range statements {
+		nodes[j] = encodeStmt(stmt)
+	}
+	return json.MarshalIndent(nodes, "", "  ")
+}
+
+// DecodeJSON is the inverse of EncodeJSON
+func DecodeJSON(data []byte) ([]Stmt, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return decodeStmts(raw)
+}
+
+type node map[string]interface{}
+
+type jsonEncoder struct{}
+
+func encodeStmt(stmt Stmt) interface{} {
+	if stmt == nil {
+		return nil
+	}
+	n, _ := stmt.Accept(jsonEncoder{})
+	return n
+}
+
+func encodeExpr(expr Expression) interface{} {
+	if expr == nil {
+		return nil
+	}
+	n, _ := expr.Accept(jsonEncoder{})
+	return n
+}
+
+func encodeStmts(statements []Stmt) []interface{} {
+	nodes := make([]interface{}, len(statements))
+	for j, stmt := range statements {
+		nodes[j] = encodeStmt(stmt)
+	}
+	return nodes
+}
+
+func (jsonEncoder) VisitBinaryExpr(b Binary) (interface{}, error) {
+	return node{"kind": "Binary", "left": encodeExpr(b.Left), "operator": b.Operator, "right": encodeExpr(b.Right)}, nil
+}
+
+func (jsonEncoder) VisitGroupingExpr(g Grouping) (interface{}, error) {
+	return node{"kind": "Grouping", "expression": encodeExpr(g.Expression)}, nil
+}
+
+func (jsonEncoder) VisitLiteralExpr(l Literal) (interface{}, error) {
+	return node{"kind": "Literal", "value": l.Value, "type": l.Type, "line": l.Line, "column": l.Column}, nil
+}
+
+func (jsonEncoder) VisitUnaryExpr(u Unary) (interface{}, error) {
+	return node{"kind": "Unary", "operator": u.Operator, "right": encodeExpr(u.Right)}, nil
+}
+
+func (jsonEncoder) VisitVariableExpr(v Variable) (interface{}, error) {
+	return node{"kind": "Variable", "name": v.Name}, nil
+}
+
+func (jsonEncoder) VisitAssignExpr(a Assign) (interface{}, error) {
+	return node{"kind": "Assign", "name": a.Name, "value": encodeExpr(a.Value)}, nil
+}
+
+func (jsonEncoder) VisitLogicalExpr(l Logical) (interface{}, error) {
+	return node{"kind": "Logical", "left": encodeExpr(l.Left), "operator": l.Operator, "right": encodeExpr(l.Right)}, nil
+}
+
+func (jsonEncoder) VisitCallExpr(c Call) (interface{}, error) {
+	arguments := make([]interface{}, len(c.Arguments))
+	for j, argument := range c.Arguments {
+		arguments[j] = encodeExpr(argument)
+	}
+	return node{"kind": "Call", "callee": encodeExpr(c.Callee), "paren": c.Paren, "arguments": arguments}, nil
+}
+
+func (jsonEncoder) VisitIndexExpr(i Index) (interface{}, error) {
+	return node{"kind": "Index", "object": encodeExpr(i.Object), "bracket": i.Bracket, "index": encodeExpr(i.Index)}, nil
+}
+
+func (jsonEncoder) VisitExprStmt(e ExprStmt) (interface{}, error) {
+	return node{"kind": "ExprStmt", "expression": encodeExpr(e.Expression)}, nil
+}
+
+func (jsonEncoder) VisitPrintStmt(p PrintStmt) (interface{}, error) {
+	return node{"kind": "PrintStmt", "expression": encodeExpr(p.Expression)}, nil
+}
+
+func (jsonEncoder) VisitVarStmt(v VarStmt) (interface{}, error) {
+	return node{"kind": "VarStmt", "name": v.Name, "initializer": encodeExpr(v.Initializer)}, nil
+}
+
+func (jsonEncoder) VisitBlockStmt(b BlockStmt) (interface{}, error) {
+	return node{"kind": "BlockStmt", "statements": encodeStmts(b.Statements)}, nil
+}
+
+func (jsonEncoder) VisitIfStmt(i IfStmt) (interface{}, error) {
+	return node{"kind": "IfStmt", "condition": encodeExpr(i.Condition), "thenBranch": encodeStmt(i.ThenBranch), "elseBranch": encodeStmt(i.ElseBranch)}, nil
+}
+
+func (jsonEncoder) VisitWhileStmt(w WhileStmt) (interface{}, error) {
+	return node{"kind": "WhileStmt", "condition": encodeExpr(w.Condition), "body": encodeStmt(w.Body)}, nil
+}
+
+func (jsonEncoder) VisitFunctionStmt(f FunctionStmt) (interface{}, error) {
+	params := f.Params
+	if params == nil {
+		params = []scanner.Token{}
+	}
+	return node{"kind": "FunctionStmt", "name": f.Name, "params": params, "rest": f.Rest, "body": encodeStmts(f.Body)}, nil
+}
+
+func (jsonEncoder) VisitReturnStmt(r ReturnStmt) (interface{}, error) {
+	return node{"kind": "ReturnStmt", "keyword": r.Keyword, "value": encodeExpr(r.Value)}, nil
+}
+
+// fields is a JSON node decoded one level deep
+type fields map[string]json.RawMessage
+
+func decodeFields(data []byte) (fields, string, error) {
+	if string(data) == "null" {
+		return nil, "", nil
+	}
+	var f fields
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, "", err
+	}
+	var kind string
+	if err := json.Unmarshal(f["kind"], &kind); err != nil {
+		return nil, "", fmt.Errorf("node without a kind: %s", data)
+	}
+	return f, kind, nil
+}
+
+// required returns the named field, or an error if it is missing or null
+func (f fields) required(name string) (json.RawMessage, error) {
+	data := f[name]
+	if len(data) == 0 || string(data) == "null" {
+		var kind string
+		json.Unmarshal(f["kind"], &kind)
+		return nil, fmt.Errorf("%s node without %q", kind, name)
+	}
+	return data, nil
+}
+
+func (f fields) token(name string) (scanner.Token, error) {
+	var token scanner.Token
+	data, err := f.required(name)
+	if err != nil {
+		return token, err
+	}
+	err = json.Unmarshal(data, &token)
+	return token, err
+}
+
+func (f fields) expr(name string) (Expression, error) {
+	data, err := f.required(name)
+	if err != nil {
+		return nil, err
+	}
+	return decodeExpr(data)
+}
+
+// optionalExpr is like expr, but a missing or null field decodes to nil
+func (f fields) optionalExpr(name string) (Expression, error) {
+	return decodeExpr(f[name])
+}
+
+func (f fields) stmt(name string) (Stmt, error) {
+	data, err := f.required(name)
+	if err != nil {
+		return nil, err
+	}
+	return decodeStmt(data)
+}
+
+// optionalStmt is like stmt, but a missing or null field decodes to nil
+func (f fields) optionalStmt(name string) (Stmt, error) {
+	return decodeStmt(f[name])
+}
+
+func (f fields) stmts(name string) ([]Stmt, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(f[name], &raw); err != nil {
+		return nil, err
+	}
+	return decodeStmts(raw)
+}
+
+func decodeStmts(raw []json.RawMessage) ([]Stmt, error) {
+	statements := make([]Stmt, len(raw))
+	for j, data := range raw {
+		if string(data) == "null" {
+			return nil, fmt.Errorf("null statement")
+		}
+		stmt, err := decodeStmt(data)
+		if err != nil {
+			return nil, err
+		}
+		statements[j] = stmt
+	}
+	return statements, nil
+}
+
+func decodeStmt(data []byte) (Stmt, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	f, kind, err := decodeFields(data)
+	if err != nil || f == nil {
+		return nil, err
+	}
+
+	switch kind {
+	case "ExprStmt":
+		expression, err := f.expr("expression")
+		return ExprStmt{Expression: expression}, err
+	case "PrintStmt":
+		expression, err := f.expr("expression")
+		return PrintStmt{Expression: expression}, err
+	case "VarStmt":
+		name, err := f.token("name")
+		if err != nil {
+			return nil, err
+		}
+		initializer, err := f.optionalExpr("initializer")
+		return VarStmt{Name: name, Initializer: initializer}, err
+	case "BlockStmt":
+		statements, err := f.stmts("statements")
+		return BlockStmt{Statements: statements}, err
+	case "IfStmt":
+		condition, err := f.expr("condition")
+		if err != nil {
+			return nil, err
+		}
+		thenBranch, err := f.stmt("thenBranch")
+		if err != nil {
+			return nil, err
+		}
+		elseBranch, err := f.optionalStmt("elseBranch")
+		return IfStmt{Condition: condition, ThenBranch: thenBranch, ElseBranch: elseBranch}, err
+	case "WhileStmt":
+		condition, err := f.expr("condition")
+		if err != nil {
+			return nil, err
+		}
+		body, err := f.stmt("body")
+		return WhileStmt{Condition: condition, Body: body}, err
+	case "FunctionStmt":
+		name, err := f.token("name")
+		if err != nil {
+			return nil, err
+		}
+		var params []scanner.Token
+		if err := json.Unmarshal(f["params"], &params); err != nil {
+			return nil, err
+		}
+		var rest bool
+		if data, ok := f["rest"]; ok {
+			if err := json.Unmarshal(data, &rest); err != nil {
+				return nil, err
+			}
+		}
+		body, err := f.stmts("body")
+		return FunctionStmt{Name: name, Params: params, Rest: rest, Body: body}, err
+	case "ReturnStmt":
+		keyword, err := f.token("keyword")
+		if err != nil {
+			return nil, err
+		}
+		value, err := f.optionalExpr("value")
+		return ReturnStmt{Keyword: keyword, Value: value}, err
+	}
+	return nil, fmt.Errorf("unknown statement kind %q", kind)
+}
+
+func decodeExpr(data []byte) (Expression, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	f, kind, err := decodeFields(data)
+	if err != nil || f == nil {
+		return nil, err
+	}
+
+	switch kind {
+	case "Binary", "Logical":
+		left, err := f.expr("left")
+		if err != nil {
+			return nil, err
+		}
+		operator, err := f.token("operator")
+		if err != nil {
+			return nil, err
+		}
+		right, err := f.expr("right")
+		if kind == "Logical" {
+			return Logical{Left: left, Operator: operator, Right: right}, err
+		}
+		return Binary{Left: left, Operator: operator, Right: right}, err
+	case "Grouping":
+		expression, err := f.expr("expression")
+		return Grouping{Expression: expression}, err
+	case "Literal":
+		var literal struct {
+			Value  interface{}       `json:"value"`
+			Type   scanner.TokenType `json:"type"`
+			Line   int               `json:"line"`
+			Column int               `json:"column"`
+		}
+		if err := json.Unmarshal(data, &literal); err != nil {
+			return nil, err
+		}
+		return Literal{Value: literal.Value, Type: literal.Type, Line: literal.Line, Column: literal.Column}, nil
+	case "Unary":
+		operator, err := f.token("operator")
+		if err != nil {
+			return nil, err
+		}
+		right, err := f.expr("right")
+		return Unary{Operator: operator, Right: right}, err
+	case "Variable":
+		name, err := f.token("name")
+		return Variable{Name: name}, err
+	case "Assign":
+		name, err := f.token("name")
+		if err != nil {
+			return nil, err
+		}
+		value, err := f.expr("value")
+		return Assign{Name: name, Value: value}, err
+	case "Call":
+		callee, err := f.expr("callee")
+		if err != nil {
+			return nil, err
+		}
+		paren, err := f.token("paren")
+		if err != nil {
+			return nil, err
+		}
+		var raw []json.RawMessage
+		if err := json.Unmarshal(f["arguments"], &raw); err != nil {
+			return nil, err
+		}
+		var arguments []Expression
+		for _, data := range raw {
+			if string(data) == "null" {
+				return nil, fmt.Errorf("null argument")
+			}
+			argument, err := decodeExpr(data)
+			if err != nil {
+				return nil, err
+			}
+			arguments = append(arguments, argument)
+		}
+		return Call{Callee: callee, Paren: paren, Arguments: arguments}, nil
+	case "Index":
+		object, err := f.expr("object")
+		if err != nil {
+			return nil, err
+		}
+		bracket, err := f.token("bracket")
+		if err != nil {
+			return nil, err
+		}
+		index, err := f.expr("index")
+		return Index{Object: object, Bracket: bracket, Index: index}, err
+	}
+	return nil, fmt.Errorf("unknown expression kind %q", kind)
+}
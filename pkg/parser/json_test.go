@@ -0,0 +1,102 @@
+package parser_test
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reilandeubank/golox/pkg/interpreter"
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+// run interprets statements, returning everything they print and report
+func run(statements []parser.Stmt) string {
+	var out bytes.Buffer
+	i := interpreter.NewInterpreter(
+		interpreter.WithStdout(&out),
+		interpreter.WithStderr(&out),
+		interpreter.WithStdin(strings.NewReader("")),
+		interpreter.WithCapabilities(),
+		interpreter.WithStepLimit(100000),
+	)
+	i.Interpret(statements)
+	return out.String()
+}
+
+// TestJSONRoundTrip checks that every program in the conformance suite that parses comes
+// back from JSON unchanged: it encodes to the same JSON again and runs the same way
+func TestJSONRoundTrip(t *testing.T) {
+	err := filepath.WalkDir(filepath.Join("..", "..", "test"), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".lox" {
+			return err
+		}
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		thisScanner := scanner.NewScanner(string(source))
+		thisScanner.ErrorOutput = io.Discard
+		thisParser := parser.NewParser(thisScanner.ScanTokens())
+		thisParser.ErrorOutput = io.Discard
+		statements, err := thisParser.Parse()
+		if err != nil || len(thisScanner.Errors) > 0 || len(thisParser.Errors) > 0 {
+			return nil
+		}
+
+		t.Run(filepath.ToSlash(path), func(t *testing.T) {
+			encoded, err := parser.EncodeJSON(statements)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := parser.DecodeJSON(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reencoded, err := parser.EncodeJSON(decoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(encoded, reencoded) {
+				t.Fatalf("re-encoding changed the JSON:\n%s\nbecame\n%s", encoded, reencoded)
+			}
+			if want, got := run(statements), run(decoded); got != want {
+				t.Errorf("the decoded program printed\n%s\nbut the original printed\n%s", got, want)
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecodeJSONErrors(t *testing.T) {
+	for _, data := range []string{
+		`{}`,
+		`[{"kind": "Nonsense"}]`,
+		`[{"kind": "ExprStmt", "expression": {"kind": "Nonsense"}}]`,
+		`[{"expression": null}]`,
+		`[null]`,
+		`[{"kind": "PrintStmt"}]`,
+		`[{"kind": "ExprStmt", "expression": null}]`,
+		`[{"kind": "VarStmt", "initializer": null}]`,
+		`[{"kind": "IfStmt", "condition": {"kind": "Literal", "value": true}, "elseBranch": null}]`,
+		`[{"kind": "WhileStmt", "body": {"kind": "BlockStmt", "statements": []}}]`,
+		`[{"kind": "ReturnStmt", "value": null}]`,
+		`[{"kind": "PrintStmt", "expression": {"kind": "Grouping"}}]`,
+		`[{"kind": "PrintStmt", "expression": {"kind": "Binary", "left": {"kind": "Literal", "value": 1}, "right": {"kind": "Literal", "value": 2}}}]`,
+		`[{"kind": "PrintStmt", "expression": {"kind": "Unary", "operator": null}}]`,
+		`[{"kind": "PrintStmt", "expression": {"kind": "Variable", "name": null}}]`,
+		`[{"kind": "ExprStmt", "expression": {"kind": "Call", "paren": {}, "arguments": []}}]`,
+		`[{"kind": "ExprStmt", "expression": {"kind": "Call", "callee": {"kind": "Variable", "name": {}}, "paren": {}, "arguments": [null]}}]`,
+	} {
+		if _, err := parser.DecodeJSON([]byte(data)); err == nil {
+			t.Errorf("DecodeJSON(%s) succeeded", data)
+		}
+	}
+}
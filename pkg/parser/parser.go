@@ -1,18 +1,42 @@
 package parser
 
 import (
+	"io"
+	"os"
+
 	"github.com/reilandeubank/golox/pkg/scanner"
 )
 
 type Parser struct {
 	Tokens []scanner.Token
 	Curr int
+
+	// ErrorOutput is where syntax errors are reported, os.Stderr unless changed
+	ErrorOutput io.Writer
+	// Errors records every error reported while parsing
+	Errors []*SyntaxError
+
+	functionDepth int // how many function bodies enclose the current token
+	nesting       int // how deeply the grammar rules being parsed have recursed
 }
 
+// maxNesting bounds the recursion of the parser, so that pathologically nested input is
+// reported as a syntax error rather than overflowing the Go stack
+const maxNesting = 1000
+
 func NewParser(tokens []scanner.Token) Parser {
+	// The parser relies on an EOF token to stop at
+	if len(tokens) == 0 || tokens[len(tokens)-1].Type != scanner.EOF {
+		line := 1
+		if len(tokens) > 0 {
+			line = tokens[len(tokens)-1].Line
+		}
+		tokens = append(tokens, scanner.NewToken(scanner.EOF, "EOF", nil, line))
+	}
 	return Parser{
 		Tokens: tokens,
 		Curr: 0,
+		ErrorOutput: os.Stderr,
 	}
 }
 
@@ -28,4 +52,17 @@ func (p *Parser) Parse() ([]Stmt, error) {
 	}
 
 	return statements, nil
-}
\ No newline at end of file
+}
+
+// ParseExpression parses source consisting of exactly one expression with no trailing ';'
+func (p *Parser) ParseExpression() (Expression, error) {
+	expr, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isAtEnd() {
+		message := "Expect end of expression."
+		return nil, p.error(p.peek(), message)
+	}
+	return expr, nil
+}
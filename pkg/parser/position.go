@@ -0,0 +1,72 @@
+package parser
+
+// StmtLine returns the line a statement starts on, or 0 if it has no source position
+// (e.g. the nodes synthesized when a for loop is desugared with no clauses)
+func StmtLine(stmt Stmt) int {
+	line, _ := StmtPosition(stmt)
+	return line
+}
+
+// ExprLine returns the line an expression starts on, or 0 if it has no source position
+func ExprLine(expr Expression) int {
+	line, _ := ExprPosition(expr)
+	return line
+}
+
+// StmtPosition returns the line and column of the first token of a statement that the
+// tree records, which tells apart statements sharing a line. Both are 0 if the statement
+// has no source position
+func StmtPosition(stmt Stmt) (line int, column int) {
+	switch s := stmt.(type) {
+	case ExprStmt:
+		return ExprPosition(s.Expression)
+	case PrintStmt:
+		return ExprPosition(s.Expression)
+	case VarStmt:
+		return s.Name.Line, s.Name.Column
+	case BlockStmt:
+		for _, inner := range s.Statements {
+			if line, column := StmtPosition(inner); line != 0 {
+				return line, column
+			}
+		}
+	case IfStmt:
+		return ExprPosition(s.Condition)
+	case WhileStmt:
+		if line, column := ExprPosition(s.Condition); line != 0 {
+			return line, column
+		}
+		return StmtPosition(s.Body)
+	case FunctionStmt:
+		return s.Name.Line, s.Name.Column
+	case ReturnStmt:
+		return s.Keyword.Line, s.Keyword.Column
+	}
+	return 0, 0
+}
+
+// ExprPosition returns the line and column an expression starts at, or 0 and 0 if it has
+// no source position
+func ExprPosition(expr Expression) (line int, column int) {
+	switch e := expr.(type) {
+	case Literal:
+		return e.Line, e.Column
+	case Grouping:
+		return ExprPosition(e.Expression)
+	case Unary:
+		return e.Operator.Line, e.Operator.Column
+	case Binary:
+		return ExprPosition(e.Left)
+	case Variable:
+		return e.Name.Line, e.Name.Column
+	case Assign:
+		return e.Name.Line, e.Name.Column
+	case Logical:
+		return ExprPosition(e.Left)
+	case Call:
+		return ExprPosition(e.Callee)
+	case Index:
+		return ExprPosition(e.Object)
+	}
+	return 0, 0
+}
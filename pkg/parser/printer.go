@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ASTPrinter renders syntax trees as S-expressions, e.g. (* (- 123) (group 45.67))
+type ASTPrinter struct{}
+
+// Print renders a single expression
+func (a ASTPrinter) Print(expr Expression) string {
+	str, _ := expr.Accept(a)
+	return str.(string)
+}
+
+// PrintStmt renders a single statement
+func (a ASTPrinter) PrintStmt(stmt Stmt) string {
+	str, _ := stmt.Accept(a)
+	return str.(string)
+}
+
+// parenthesize wraps name and the rendered parts in parentheses. Parts may be
+// expressions, statements, or strings that are written as is
+func (a ASTPrinter) parenthesize(name string, parts ...interface{}) string {
+	var builder strings.Builder
+	builder.WriteString("(" + name)
+	for _, part := range parts {
+		builder.WriteString(" ")
+		switch p := part.(type) {
+		case Expression:
+			builder.WriteString(a.Print(p))
+		case Stmt:
+			builder.WriteString(a.PrintStmt(p))
+		case string:
+			builder.WriteString(p)
+		}
+	}
+	builder.WriteString(")")
+	return builder.String()
+}
+
+func (a ASTPrinter) VisitBinaryExpr(b Binary) (interface{}, error) {
+	return a.parenthesize(b.Operator.Lexeme, b.Left, b.Right), nil
+}
+
+func (a ASTPrinter) VisitGroupingExpr(g Grouping) (interface{}, error) {
+	return a.parenthesize("group", g.Expression), nil
+}
+
+func (a ASTPrinter) VisitLiteralExpr(l Literal) (interface{}, error) {
+	switch value := l.Value.(type) {
+	case nil:
+		return "nil", nil
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64), nil
+	case string:
+		return strconv.Quote(value), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	}
+	return "?", nil
+}
+
+func (a ASTPrinter) VisitUnaryExpr(u Unary) (interface{}, error) {
+	return a.parenthesize(u.Operator.Lexeme, u.Right), nil
+}
+
+func (a ASTPrinter) VisitVariableExpr(v Variable) (interface{}, error) {
+	return v.Name.Lexeme, nil
+}
+
+func (a ASTPrinter) VisitAssignExpr(as Assign) (interface{}, error) {
+	return a.parenthesize("=", as.Name.Lexeme, as.Value), nil
+}
+
+func (a ASTPrinter) VisitLogicalExpr(l Logical) (interface{}, error) {
+	return a.parenthesize(l.Operator.Lexeme, l.Left, l.Right), nil
+}
+
+func (a ASTPrinter) VisitCallExpr(c Call) (interface{}, error) {
+	parts := []interface{}{c.Callee}
+	for _, argument := range c.Arguments {
+		parts = append(parts, argument)
+	}
+	return a.parenthesize("call", parts...), nil
+}
+
+func (a ASTPrinter) VisitIndexExpr(i Index) (interface{}, error) {
+	return a.parenthesize("index", i.Object, i.Index), nil
+}
+
+func (a ASTPrinter) VisitExprStmt(e ExprStmt) (interface{}, error) {
+	return a.parenthesize(";", e.Expression), nil
+}
+
+func (a ASTPrinter) VisitPrintStmt(p PrintStmt) (interface{}, error) {
+	return a.parenthesize("print", p.Expression), nil
+}
+
+func (a ASTPrinter) VisitVarStmt(v VarStmt) (interface{}, error) {
+	if v.Initializer == nil {
+		return a.parenthesize("var", v.Name.Lexeme), nil
+	}
+	return a.parenthesize("var", v.Name.Lexeme, "=", v.Initializer), nil
+}
+
+func (a ASTPrinter) VisitBlockStmt(b BlockStmt) (interface{}, error) {
+	parts := make([]interface{}, len(b.Statements))
+	for j, stmt := range b.Statements {
+		parts[j] = stmt
+	}
+	return a.parenthesize("block", parts...), nil
+}
+
+func (a ASTPrinter) VisitIfStmt(i IfStmt) (interface{}, error) {
+	if i.ElseBranch == nil {
+		return a.parenthesize("if", i.Condition, i.ThenBranch), nil
+	}
+	return a.parenthesize("if-else", i.Condition, i.ThenBranch, i.ElseBranch), nil
+}
+
+func (a ASTPrinter) VisitWhileStmt(w WhileStmt) (interface{}, error) {
+	return a.parenthesize("while", w.Condition, w.Body), nil
+}
+
+func (a ASTPrinter) VisitFunctionStmt(f FunctionStmt) (interface{}, error) {
+	params := make([]string, len(f.Params))
+	for j, param := range f.Params {
+		params[j] = param.Lexeme
+	}
+	if f.Rest {
+		params[len(params)-1] = "..." + params[len(params)-1]
+	}
+	parts := []interface{}{f.Name.Lexeme + "(" + strings.Join(params, " ") + ")"}
+	for _, stmt := range f.Body {
+		parts = append(parts, stmt)
+	}
+	return a.parenthesize("fun", parts...), nil
+}
+
+func (a ASTPrinter) VisitReturnStmt(r ReturnStmt) (interface{}, error) {
+	if r.Value == nil {
+		return "(return)", nil
+	}
+	return a.parenthesize("return", r.Value), nil
+}
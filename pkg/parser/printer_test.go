@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+// parse parses source, failing the test if it has syntax errors
+func parse(t *testing.T, source string) []Stmt {
+	t.Helper()
+	thisScanner := scanner.NewScanner(source)
+	thisScanner.ErrorOutput = io.Discard
+	thisParser := NewParser(thisScanner.ScanTokens())
+	thisParser.ErrorOutput = io.Discard
+	statements, err := thisParser.Parse()
+	if err != nil || len(thisScanner.Errors) > 0 || len(thisParser.Errors) > 0 {
+		t.Fatalf("%q doesn't parse: %v", source, err)
+	}
+	return statements
+}
+
+func TestPrintExpression(t *testing.T) {
+	// The example from chapter 5 of Crafting Interpreters
+	expr := Binary{
+		Left:     Unary{Operator: scanner.NewToken(scanner.MINUS, "-", nil, 1), Right: Literal{Value: 123.0}},
+		Operator: scanner.NewToken(scanner.STAR, "*", nil, 1),
+		Right:    Grouping{Expression: Literal{Value: 45.67}},
+	}
+	if got, want := (ASTPrinter{}).Print(expr), "(* (- 123) (group 45.67))"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestPrintStmt(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"print -123 * (45.67);", "(print (* (- 123) (group 45.67)))"},
+		{`var s = "a" + nil;`, `(var s = (+ "a" nil))`},
+		{"var x;", "(var x)"},
+		{"x = y = true;", "(; (= x (= y true)))"},
+		{"a or b and !c;", "(; (or a (and b (! c))))"},
+		{"{ var a = 1; print a; }", "(block (var a = 1) (print a))"},
+		{"if (a) print 1;", "(if a (print 1))"},
+		{"if (a) print 1; else print 2;", "(if-else a (print 1) (print 2))"},
+		{"while (a < 3) a = a + 1;", "(while (< a 3) (; (= a (+ a 1))))"},
+		{"fun f(a, ...rest) { return rest[0]; }", "(fun f(a ...rest) (return (index rest 0)))"},
+		{"fun g() { return; }", "(fun g() (return))"},
+		{"f(1)(2, 3);", "(; (call (call f 1) 2 3))"},
+	}
+	for _, test := range tests {
+		statements := parse(t, test.source)
+		var printed []string
+		for _, stmt := range statements {
+			printed = append(printed, ASTPrinter{}.PrintStmt(stmt))
+		}
+		if got := strings.Join(printed, "\n"); got != test.want {
+			t.Errorf("%s\ngot  %s\nwant %s", test.source, got, test.want)
+		}
+	}
+}
@@ -63,6 +63,7 @@ func (w WhileStmt) Accept(visitor StmtVisitor) (interface{}, error) {
 type FunctionStmt struct {
 	Name        scanner.Token
 	Params      []scanner.Token
+	Rest        bool // the last of Params collects any arguments beyond the others
 	Body        []Stmt
 }
 
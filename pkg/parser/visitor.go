@@ -9,6 +9,7 @@ type ExprVisitor interface {
 	VisitAssignExpr(a Assign) (interface{}, error)
 	VisitLogicalExpr(l Logical) (interface{}, error)
 	VisitCallExpr(c Call) (interface{}, error)
+	VisitIndexExpr(i Index) (interface{}, error)
 	// VisitGetExpr(g Get) (interface{}, error)
 	// VisitSetExpr(s Set) (interface{}, error)
 	// VisitThisExpr(t This) (interface{}, error)
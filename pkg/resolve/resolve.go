@@ -0,0 +1,273 @@
+// Package resolve links each identifier in a Lox program to the declaration it names,
+// following the interpreter's scoping rules, for tools such as vet and the language server.
+package resolve
+
+import (
+	"github.com/reilandeubank/golox/pkg/interpreter"
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+type Kind int
+
+const (
+	Variable Kind = iota
+	Parameter
+	Function
+	Native
+)
+
+// Binding is a name the program declares, or a built-in one
+type Binding struct {
+	Name       scanner.Token // only the Lexeme is set for natives
+	Kind       Kind
+	Global     bool
+	Function   *parser.FunctionStmt    // set for functions
+	Native     interpreter.LoxCallable // set for natives, except for constants such as PI
+	Owner      *Binding                // the function whose body declares it, nil outside any
+	Shadows    *Binding                // a binding of the same name it hides, if any
+	Used       bool
+	Reassigned bool // assigned to, or declared again at the top level
+}
+
+// Key identifies a token by its position
+type Key struct {
+	Line   int
+	Column int
+}
+
+func KeyOf(t scanner.Token) Key {
+	return Key{t.Line, t.Column}
+}
+
+type Resolution struct {
+	Bindings   []*Binding       // those the program declares, in order of declaration
+	References map[Key]*Binding // keyed by each identifier naming a binding, declarations included
+	Undeclared []scanner.Token  // names assigned to without being declared
+}
+
+type scope map[string]*Binding
+
+type resolver struct {
+	resolution *Resolution
+	scopes     []scope
+	owner      *Binding // the function being resolved
+}
+
+// Resolve resolves a program that parsed without errors
+func Resolve(statements []parser.Stmt) *Resolution {
+	globals := make(scope)
+	for name, native := range interpreter.Natives() {
+		globals[name] = &Binding{Name: scanner.Token{Lexeme: name}, Kind: Native, Global: true, Native: native}
+	}
+	for name := range interpreter.Constants() {
+		globals[name] = &Binding{Name: scanner.Token{Lexeme: name}, Kind: Native, Global: true}
+	}
+	r := &resolver{resolution: &Resolution{References: make(map[Key]*Binding)}, scopes: []scope{globals}}
+
+	// Globals may be used by functions before their declaration is executed, so they are
+	// all declared up front
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case parser.VarStmt:
+			r.declareGlobal(s.Name, Variable, nil)
+		case parser.FunctionStmt:
+			function := s
+			r.declareGlobal(s.Name, Function, &function)
+		}
+	}
+	r.statements(statements)
+	return r.resolution
+}
+
+func (r *resolver) declareGlobal(name scanner.Token, kind Kind, function *parser.FunctionStmt) {
+	globals := r.scopes[0]
+	if existing, ok := globals[name.Lexeme]; ok && existing.Kind != Native {
+		// Redefining a global makes its value depend on execution order
+		existing.Reassigned = true
+		r.resolution.References[KeyOf(name)] = existing
+		return
+	}
+	r.add(globals, &Binding{Name: name, Kind: kind, Global: true, Function: function})
+}
+
+// declare adds a binding to the innermost scope. At the top level it returns the one
+// declared up front
+func (r *resolver) declare(name scanner.Token, kind Kind) *Binding {
+	if len(r.scopes) == 1 {
+		return r.scopes[0][name.Lexeme]
+	}
+	innermost := r.scopes[len(r.scopes)-1]
+	b := &Binding{Name: name, Kind: kind, Owner: r.owner}
+	if _, ok := innermost[name.Lexeme]; !ok {
+		b.Shadows = r.lookup(name.Lexeme)
+	}
+	r.add(innermost, b)
+	return b
+}
+
+func (r *resolver) add(s scope, b *Binding) {
+	s[b.Name.Lexeme] = b
+	r.resolution.Bindings = append(r.resolution.Bindings, b)
+	r.resolution.References[KeyOf(b.Name)] = b
+}
+
+func (r *resolver) lookup(name string) *Binding {
+	for j := len(r.scopes) - 1; j >= 0; j-- {
+		if b, ok := r.scopes[j][name]; ok {
+			return b
+		}
+	}
+	return nil
+}
+
+// reference links name to the binding it refers to, if there is one
+func (r *resolver) reference(name scanner.Token) *Binding {
+	b := r.lookup(name.Lexeme)
+	if b != nil {
+		r.resolution.References[KeyOf(name)] = b
+	}
+	return b
+}
+
+func (r *resolver) beginScope() {
+	r.scopes = append(r.scopes, make(scope))
+}
+
+func (r *resolver) endScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+func (r *resolver) statements(statements []parser.Stmt) {
+	for _, stmt := range statements {
+		r.stmt(stmt)
+	}
+}
+
+func (r *resolver) stmt(stmt parser.Stmt) {
+	if stmt != nil {
+		stmt.Accept(r)
+	}
+}
+
+func (r *resolver) expr(expr parser.Expression) {
+	if expr != nil {
+		expr.Accept(r)
+	}
+}
+
+func (r *resolver) VisitExprStmt(e parser.ExprStmt) (interface{}, error) {
+	r.expr(e.Expression)
+	return nil, nil
+}
+
+func (r *resolver) VisitPrintStmt(p parser.PrintStmt) (interface{}, error) {
+	r.expr(p.Expression)
+	return nil, nil
+}
+
+func (r *resolver) VisitVarStmt(v parser.VarStmt) (interface{}, error) {
+	r.expr(v.Initializer)
+	r.declare(v.Name, Variable)
+	return nil, nil
+}
+
+func (r *resolver) VisitBlockStmt(b parser.BlockStmt) (interface{}, error) {
+	r.beginScope()
+	r.statements(b.Statements)
+	r.endScope()
+	return nil, nil
+}
+
+func (r *resolver) VisitIfStmt(i parser.IfStmt) (interface{}, error) {
+	r.expr(i.Condition)
+	r.stmt(i.ThenBranch)
+	r.stmt(i.ElseBranch)
+	return nil, nil
+}
+
+func (r *resolver) VisitWhileStmt(w parser.WhileStmt) (interface{}, error) {
+	r.expr(w.Condition)
+	r.stmt(w.Body)
+	return nil, nil
+}
+
+func (r *resolver) VisitFunctionStmt(f parser.FunctionStmt) (interface{}, error) {
+	b := r.declare(f.Name, Function)
+	if len(r.scopes) > 1 {
+		b.Function = &f
+	}
+
+	outer := r.owner
+	r.owner = b
+	r.beginScope()
+	for _, param := range f.Params {
+		r.declare(param, Parameter)
+	}
+	r.statements(f.Body)
+	r.endScope()
+	r.owner = outer
+	return nil, nil
+}
+
+func (r *resolver) VisitReturnStmt(ret parser.ReturnStmt) (interface{}, error) {
+	r.expr(ret.Value)
+	return nil, nil
+}
+
+func (r *resolver) VisitBinaryExpr(b parser.Binary) (interface{}, error) {
+	r.expr(b.Left)
+	r.expr(b.Right)
+	return nil, nil
+}
+
+func (r *resolver) VisitGroupingExpr(g parser.Grouping) (interface{}, error) {
+	r.expr(g.Expression)
+	return nil, nil
+}
+
+func (r *resolver) VisitLiteralExpr(l parser.Literal) (interface{}, error) {
+	return nil, nil
+}
+
+func (r *resolver) VisitUnaryExpr(u parser.Unary) (interface{}, error) {
+	r.expr(u.Right)
+	return nil, nil
+}
+
+func (r *resolver) VisitVariableExpr(v parser.Variable) (interface{}, error) {
+	if b := r.reference(v.Name); b != nil {
+		b.Used = true
+	}
+	return nil, nil
+}
+
+func (r *resolver) VisitAssignExpr(a parser.Assign) (interface{}, error) {
+	r.expr(a.Value)
+	if b := r.reference(a.Name); b != nil {
+		b.Reassigned = true
+	} else {
+		r.resolution.Undeclared = append(r.resolution.Undeclared, a.Name)
+	}
+	return nil, nil
+}
+
+func (r *resolver) VisitLogicalExpr(l parser.Logical) (interface{}, error) {
+	r.expr(l.Left)
+	r.expr(l.Right)
+	return nil, nil
+}
+
+func (r *resolver) VisitCallExpr(c parser.Call) (interface{}, error) {
+	r.expr(c.Callee)
+	for _, argument := range c.Arguments {
+		r.expr(argument)
+	}
+	return nil, nil
+}
+
+func (r *resolver) VisitIndexExpr(i parser.Index) (interface{}, error) {
+	r.expr(i.Object)
+	r.expr(i.Index)
+	return nil, nil
+}
@@ -0,0 +1,91 @@
+package resolve
+
+import (
+	"io"
+	"testing"
+
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+func resolve(t *testing.T, source string) *Resolution {
+	t.Helper()
+	thisScanner := scanner.NewScanner(source)
+	thisScanner.ErrorOutput = io.Discard
+	thisParser := parser.NewParser(thisScanner.ScanTokens())
+	thisParser.ErrorOutput = io.Discard
+	statements, err := thisParser.Parse()
+	if err != nil || len(thisScanner.Errors) > 0 || len(thisParser.Errors) > 0 {
+		t.Fatalf("%q doesn't parse", source)
+	}
+	return Resolve(statements)
+}
+
+// at returns the binding the identifier at line and column refers to
+func at(t *testing.T, r *Resolution, line, column int) *Binding {
+	t.Helper()
+	b, ok := r.References[Key{line, column}]
+	if !ok {
+		t.Fatalf("nothing at %d:%d refers to a binding", line, column)
+	}
+	return b
+}
+
+func TestResolve(t *testing.T) {
+	r := resolve(t, `fun f(a) {
+    var x = a;
+    {
+        var x = clock();
+        print x;
+    }
+    return g();
+}
+fun g() { return PI; }
+y = f(1);
+`)
+
+	f := at(t, r, 1, 5)
+	if f.Kind != Function || !f.Global || f.Function == nil || !f.Used {
+		t.Errorf("f is %+v", f)
+	}
+	if a := at(t, r, 2, 13); a.Kind != Parameter || a.Owner != f || at(t, r, 1, 7) != a {
+		t.Errorf("a is %+v", a)
+	}
+
+	outer, inner := at(t, r, 2, 9), at(t, r, 4, 13)
+	if outer.Used || outer.Owner != f {
+		t.Errorf("outer x is %+v", outer)
+	}
+	if inner.Shadows != outer || !inner.Used || at(t, r, 5, 15) != inner {
+		t.Errorf("inner x is %+v", inner)
+	}
+
+	// g is called before its declaration runs, which is fine for globals
+	if g := at(t, r, 7, 12); g != at(t, r, 9, 5) {
+		t.Errorf("g resolves to %+v", g)
+	}
+	if clock := at(t, r, 4, 17); clock.Kind != Native || clock.Native == nil {
+		t.Errorf("clock is %+v", clock)
+	}
+	if pi := at(t, r, 9, 18); pi.Kind != Native || pi.Native != nil {
+		t.Errorf("PI is %+v", pi)
+	}
+
+	if len(r.Undeclared) != 1 || r.Undeclared[0].Lexeme != "y" {
+		t.Errorf("undeclared names are %v, want y", r.Undeclared)
+	}
+	if len(r.Bindings) != 5 {
+		t.Errorf("got %d bindings, want f, g, a and both x", len(r.Bindings))
+	}
+}
+
+func TestRedefinedGlobal(t *testing.T) {
+	r := resolve(t, "var a = 1;\nvar a = 2;\nvar clock = 3;\nprint a + clock;\n")
+	a := at(t, r, 1, 5)
+	if at(t, r, 2, 5) != a || !a.Reassigned {
+		t.Errorf("a is %+v, want one binding that is reassigned", a)
+	}
+	if clock := at(t, r, 4, 11); clock.Kind != Variable || clock.Reassigned {
+		t.Errorf("clock is %+v, want the program's variable", clock)
+	}
+}
@@ -0,0 +1,20 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/reilandeubank/golox/internal/corpus"
+)
+
+func BenchmarkScanTokens(b *testing.B) {
+	for _, w := range corpus.Benchmarks(b) {
+		source := w.Source
+		b.Run(w.Name, func(b *testing.B) {
+			b.SetBytes(int64(len(source)))
+			for n := 0; n < b.N; n++ {
+				s := NewScanner(source)
+				s.ScanTokens()
+			}
+		})
+	}
+}
@@ -2,26 +2,19 @@ package scanner
 
 import (
 	"fmt"
-	// "log"
-	"os"
+	"io"
 	//"unicode/utf8"
 )
 
-var hadErrorFlag bool = false
-
-func HadError() bool {
-	return hadErrorFlag
-}
-
-func SetErrorFlag(val bool) {
-	hadErrorFlag = val
+// ScanError is an error found while scanning, as recorded in Scanner.Errors
+type ScanError struct {
+	Line    int
+	Column  int
+	Message string
 }
 
-func LoxError(line int, message string) {
-	Report(line, "", message)
+// Report writes a syntax error found at line to w. The parser reports its errors the
+// same way
+func Report(w io.Writer, line int, where string, message string) {
+	fmt.Fprintf(w, "[line %d] Parse Error%s: %s\n", line, where, message)
 }
-
-func Report(line int, where string, message string) {
-	fmt.Fprintf(os.Stderr, "[line %d] Parse Error%s: %s\n", line, where, message)
-	hadErrorFlag = true
-}
\ No newline at end of file
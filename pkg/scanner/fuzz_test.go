@@ -0,0 +1,33 @@
+package scanner
+
+import (
+	"io"
+	"testing"
+
+	"github.com/reilandeubank/golox/internal/corpus"
+)
+
+func FuzzScanTokens(f *testing.F) {
+	corpus.Seeds(f,
+		"\"unterminated",
+		"print \"héllo\"; // ünïcode",
+		"var ñ = 1;",
+	)
+	f.Fuzz(func(t *testing.T, source string) {
+		s := NewScanner(source)
+		s.ErrorOutput = io.Discard
+		tokens := s.ScanTokens()
+		if len(tokens) == 0 || tokens[len(tokens)-1].Type != EOF {
+			t.Fatalf("tokens don't end with EOF: %v", tokens)
+		}
+		for _, token := range tokens {
+			if token.Line < 1 {
+				t.Errorf("token %v has line %d", token, token.Line)
+			}
+		}
+		// Every byte is either in a token, whitespace, a comment or reported as an error
+		if s.Curr != len(source) {
+			t.Errorf("stopped at byte %d of %d", s.Curr, len(source))
+		}
+	})
+}
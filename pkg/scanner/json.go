@@ -0,0 +1,27 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MarshalJSON encodes a TokenType by name, e.g. "IDENTIFIER"
+func (t TokenType) MarshalJSON() ([]byte, error) {
+	if t < LEFT_PAREN || t > EOF {
+		return nil, fmt.Errorf("unknown token type %d", int(t))
+	}
+	return json.Marshal(t.String())
+}
+
+func (t *TokenType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	tokenType, err := ParseTokenType(name)
+	if err != nil {
+		return err
+	}
+	*t = tokenType
+	return nil
+}
@@ -2,9 +2,12 @@ package scanner
 
 import (
 	"fmt"
+	"io"
 	// "log"
-	// "os"
+	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"unicode"
 	"unicode/utf8"
 )
@@ -26,12 +29,33 @@ var keywords = map[string]TokenType{
 	"while":  WHILE,
 }
 
+// Keywords returns the reserved words of the language
+func Keywords() []string {
+	words := make([]string, 0, len(keywords))
+	for word := range keywords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
 type Scanner struct {
 	Source string
 	Tokens []Token
 	Start  int
 	Curr   int
 	Line   int
+
+	lineStart    int // offset of the first byte of Line
+	startColumn  int
+	keepComments bool
+
+	// UnterminatedString is set when the source ends inside a string literal
+	UnterminatedString bool
+	// ErrorOutput is where errors are reported, os.Stderr unless changed
+	ErrorOutput io.Writer
+	// Errors records every error reported while scanning
+	Errors []ScanError
 }
 
 func NewScanner(sourceText string) Scanner {
@@ -42,17 +66,27 @@ func NewScanner(sourceText string) Scanner {
 		Start:  0,
 		Curr:   0,
 		Line:   1,
+		ErrorOutput: os.Stderr,
 	}
 }
 
+// NewScannerWithComments returns a scanner that emits a COMMENT token for each // comment
+// rather than discarding it, for tools such as the formatter that reprint source
+func NewScannerWithComments(sourceText string) Scanner {
+	s := NewScanner(sourceText)
+	s.keepComments = true
+	return s
+}
+
 func (s *Scanner) isAtEnd() bool {
-	length := utf8.RuneCountInString(s.Source)
-	return s.Curr >= length
+	return s.Curr >= len(s.Source)
 }
 
+// advance consumes the next character. Curr is a byte offset, so a multi-byte UTF-8
+// character moves it by more than one; an invalid byte comes back as utf8.RuneError
 func (s *Scanner) advance() rune {
-	ch := rune(s.Source[s.Curr])
-	s.Curr++
+	ch, size := utf8.DecodeRuneInString(s.Source[s.Curr:])
+	s.Curr += size
 	return ch
 }
 
@@ -60,7 +94,16 @@ func (s *Scanner) peek() rune {
 	if s.isAtEnd() {
 		return '\000'
 	}
-	return rune(s.Source[s.Curr])
+	ch, _ := utf8.DecodeRuneInString(s.Source[s.Curr:])
+	return ch
+}
+
+func isDigit(ch rune) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isAlpha(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
 }
 
 func (s *Scanner) addToken(thisType TokenType) {
@@ -70,7 +113,7 @@ func (s *Scanner) addToken(thisType TokenType) {
 
 func (s *Scanner) addTokenWithTypeAndLiteral(thisType TokenType, literal interface{}) {
 	text := s.Source[s.Start:s.Curr]
-	s.Tokens = append(s.Tokens, Token{Type: thisType, Lexeme: text, Literal: literal, Line: s.Line})
+	s.Tokens = append(s.Tokens, Token{Type: thisType, Lexeme: text, Literal: literal, Line: s.Line, Column: s.startColumn})
 }
 
 func (s *Scanner) ScanTokens() []Token {
@@ -78,11 +121,12 @@ func (s *Scanner) ScanTokens() []Token {
 	// Note that s.curr is not incremented in Number, String, or Identifier readers since they handle their own iteration
 	for !s.isAtEnd() {
 		s.Start = s.Curr
+		s.startColumn = s.Curr - s.lineStart + 1
 		s.ScanToken()
 	}
 
 	// Add EOF token
-	s.Tokens = append(s.Tokens, Token{EOF, "EOF", nil, s.Line})
+	s.Tokens = append(s.Tokens, Token{EOF, "EOF", nil, s.Line, s.Curr - s.lineStart + 1})
 	return s.Tokens
 }
 
@@ -93,8 +137,16 @@ func (s *Scanner) ScanToken() {
 	case ')': s.addToken(RIGHT_PAREN)
 	case '{': s.addToken(LEFT_BRACE)
 	case '}': s.addToken(RIGHT_BRACE)
+	case '[': s.addToken(LEFT_BRACKET)
+	case ']': s.addToken(RIGHT_BRACKET)
 	case ',': s.addToken(COMMA)
-	case '.': s.addToken(DOT)
+	case '.':
+		if strings.HasPrefix(s.Source[s.Curr:], "..") {
+			s.Curr += 2
+			s.addToken(ELLIPSIS)
+		} else {
+			s.addToken(DOT)
+		}
 	case '-': s.addToken(MINUS)
 	case '+': s.addToken(PLUS)
 	case ';': s.addToken(SEMICOLON)
@@ -130,6 +182,9 @@ func (s *Scanner) ScanToken() {
 				s.advance()
 				//fmt.Println(s.peek())
 			}
+			if s.keepComments {
+				s.addToken(COMMENT)
+			}
 		} else {
 			s.addToken(SLASH)
 		}
@@ -139,21 +194,31 @@ func (s *Scanner) ScanToken() {
 	case '\t': 
 	case '\n':
 		s.Line++
+		s.lineStart = s.Curr
 	// Handle strings
 	case '"': s.tokenizeString()
 	default:
-		if unicode.IsDigit(rune(ch)) {
+		if isDigit(ch) {
 			s.tokenizeNumber()
-		} else if unicode.IsLetter(rune(ch)) || ch == '_' {
+		} else if isAlpha(ch) {
 			s.tokenizeIdentifier()
+		} else if ch == utf8.RuneError {
+			errorStr := fmt.Sprintf("Invalid UTF-8 at line %d", s.Line)
+			s.error(errorStr)
 		} else {
 			errorStr := fmt.Sprintf("Unexpected character: %c at line %d", ch, s.Line)
-			LoxError(s.Line, errorStr)
+			s.error(errorStr)
 		}
 	}
 	
 }
 
+// error reports message at the current token and records it in s.Errors
+func (s *Scanner) error(message string) {
+	s.Errors = append(s.Errors, ScanError{Line: s.Line, Column: s.startColumn, Message: message})
+	Report(s.ErrorOutput, s.Line, "", message)
+}
+
 func (s *Scanner) match(expected rune) bool {
 	if s.isAtEnd() {
 		return false
@@ -184,6 +249,7 @@ func (s *Scanner) tokenizeString() {
 		// Handle newlines
 		if s.Source[s.Curr] == '\n' {
 			s.Line++
+			s.lineStart = s.Curr + 1
 		}
 
 		s.Curr++
@@ -193,8 +259,9 @@ func (s *Scanner) tokenizeString() {
 	if unterminated {
 		// Set current position to end of file to prevent further iteration
 		s.Curr = len(s.Source)
+		s.UnterminatedString = true
 		errorStr := fmt.Sprintf("Unterminated string at line %d", s.Line)
-		LoxError(s.Line, errorStr)
+		s.error(errorStr)
 	} else {
 		// Return token using substring created from initial and current positions
 		s.addTokenWithTypeAndLiteral(STRING, s.Source[s.Start+1 : s.Curr-1])
@@ -209,14 +276,14 @@ func (s *Scanner) tokenizeNumber() {
 	foundDot := false
 
 	// Iterate until end of number or end of file
-	for s.Curr < len(s.Source) && (unicode.IsDigit(rune(s.Source[s.Curr])) || s.Source[s.Curr] == '.') {
+	for !s.isAtEnd() && (isDigit(s.peek()) || s.peek() == '.') {
 
 		// Check for dot
 		if s.Source[s.Curr] == '.' {
 			// Return error if dot has already been found
 			if foundDot {
 				errorStr := fmt.Sprintf("Invalid number at line %d", s.Line)
-				LoxError(s.Line, errorStr)
+				s.error(errorStr)
 			}
 			// Otherwise, set foundDot to true and skip to next character
 			foundDot = true
@@ -229,7 +296,7 @@ func (s *Scanner) tokenizeNumber() {
 
 	if err != nil {
         errorStr := fmt.Sprintf("Invalid number at line %d", s.Line)
-		LoxError(s.Line, errorStr)
+		s.error(errorStr)
     }
 	// Return token using substring created from initial and current positions
 	s.addTokenWithTypeAndLiteral(NUMBER, floatVal)
@@ -239,8 +306,8 @@ func (s *Scanner) tokenizeNumber() {
 // Note that although an error is never returned, it is good practice to provide support for it
 func (s *Scanner) tokenizeIdentifier() {
 	// Iterate until end of identifier or end of file
-	for s.Curr < len(s.Source) && (unicode.IsLetter(rune(s.Source[s.Curr])) || unicode.IsDigit(rune(s.Source[s.Curr])) || s.Source[s.Curr] == '_') {
-		s.Curr++
+	for !s.isAtEnd() && (isAlpha(s.peek()) || isDigit(s.peek())) {
+		s.advance()
 	}
 
 	// Check for existing keyword
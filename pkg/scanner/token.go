@@ -4,12 +4,13 @@ import (
 	"fmt"
 )
 
-// Token struct represents a token with its type, lexeme, literal value, and the line it appears on.
+// Token struct represents a token with its type, lexeme, literal value, and the position it appears at.
 type Token struct {
-	Type    TokenType
-	Lexeme  string
-	Literal interface{}
-	Line    int
+	Type    TokenType   `json:"type"`
+	Lexeme  string      `json:"lexeme"`
+	Literal interface{} `json:"literal"`
+	Line    int         `json:"line"`
+	Column  int         `json:"column"` // 1-based byte offset within Line, 0 if unknown
 }
 
 // NewToken is a constructor function for creating a new Token instance.
@@ -24,7 +25,7 @@ func NewToken(tokenType TokenType, lexeme string, literal interface{}, line int)
 
 // String method provides a string representation of the Token.
 func (t Token) String() string {
-	return fmt.Sprintf("%d %s %v", t.Type, t.Lexeme, t.Literal)
+	return fmt.Sprintf("%s %s %v", t.Type, t.Lexeme, t.Literal)
 }
 
 func Tester() string {
@@ -1,5 +1,9 @@
 package scanner
 
+import "fmt"
+
+//go:generate stringer -type=TokenType
+
 type TokenType int
 
 const (
@@ -8,6 +12,8 @@ const (
 	RIGHT_PAREN
 	LEFT_BRACE
 	RIGHT_BRACE
+	LEFT_BRACKET
+	RIGHT_BRACKET
 	COMMA
 	DOT
 	MINUS
@@ -16,7 +22,7 @@ const (
 	SLASH
 	STAR
 
-	// One or two character tokens.
+	// One, two or three character tokens.
 	BANG
 	BANG_EQUAL
 	EQUAL
@@ -25,6 +31,7 @@ const (
 	GREATER_EQUAL
 	LESS
 	LESS_EQUAL
+	ELLIPSIS
 
 	// Literals.
 	IDENTIFIER
@@ -47,7 +54,18 @@ const (
 	VAR
 	WHILE
 
+	COMMENT
 	WHITESPACE
 	OTHER
 	EOF
 )
+
+// ParseTokenType is the inverse of TokenType.String
+func ParseTokenType(name string) (TokenType, error) {
+	for t := LEFT_PAREN; t <= EOF; t++ {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+	return OTHER, fmt.Errorf("unknown token type %q", name)
+}
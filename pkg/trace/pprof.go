@@ -0,0 +1,190 @@
+package trace
+
+import (
+	"compress/gzip"
+	"io"
+	"sort"
+	"strings"
+)
+
+// A minimal encoder for the profile.proto format read by go tool pprof, documented at
+// https://github.com/google/pprof/blob/main/proto/profile.proto
+
+type protobuf struct {
+	data []byte
+}
+
+func (b *protobuf) varint(x uint64) {
+	for x >= 0x80 {
+		b.data = append(b.data, byte(x)|0x80)
+		x >>= 7
+	}
+	b.data = append(b.data, byte(x))
+}
+
+func (b *protobuf) uint64(field int, x uint64) {
+	b.varint(uint64(field)<<3 | 0) // varint wire type
+	b.varint(x)
+}
+
+func (b *protobuf) int64(field int, x int64) {
+	b.uint64(field, uint64(x))
+}
+
+func (b *protobuf) bytes(field int, data []byte) {
+	b.varint(uint64(field)<<3 | 2) // length-delimited wire type
+	b.varint(uint64(len(data)))
+	b.data = append(b.data, data...)
+}
+
+func (b *protobuf) string(field int, s string) {
+	b.bytes(field, []byte(s))
+}
+
+func (b *protobuf) message(field int, build func(m *protobuf)) {
+	var m protobuf
+	build(&m)
+	b.bytes(field, m.data)
+}
+
+func (b *protobuf) packed(field int, values []uint64) {
+	var m protobuf
+	for _, v := range values {
+		m.varint(v)
+	}
+	b.bytes(field, m.data)
+}
+
+// Field numbers from profile.proto
+const (
+	profileSampleType    = 1
+	profileSample        = 2
+	profileLocation      = 4
+	profileFunction      = 5
+	profileStringTable   = 6
+	profileTimeNanos     = 9
+	profileDurationNanos = 10
+	profilePeriodType    = 11
+	profilePeriod        = 12
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	locationID   = 1
+	locationLine = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID         = 1
+	functionName       = 2
+	functionSystemName = 3
+	functionFilename   = 4
+	functionStartLine  = 5
+)
+
+// WriteProfile writes the profile in the gzipped protocol buffer format go tool pprof
+// reads. Each sample is a call stack with the statements executed and the nanoseconds
+// spent in it. filename is the script's path, shown as the source of every function
+func (p *Profiler) WriteProfile(w io.Writer, filename string) error {
+	table := []string{""}
+	stringIndex := map[string]int64{"": 0}
+	intern := func(s string) int64 {
+		if index, ok := stringIndex[s]; ok {
+			return index
+		}
+		stringIndex[s] = int64(len(table))
+		table = append(table, s)
+		return stringIndex[s]
+	}
+
+	// Order the samples so the output is deterministic
+	keys := make([]string, 0, len(p.samples))
+	for key := range p.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	functionIDs := make(map[Function]uint64)
+	locationIDs := make(map[location]uint64)
+	var functions, locations []func(m *protobuf)
+
+	var profile protobuf
+	for _, valueType := range [][2]string{{"statements", "count"}, {"time", "nanoseconds"}} {
+		valueType := valueType
+		profile.message(profileSampleType, func(m *protobuf) {
+			m.int64(valueTypeType, intern(valueType[0]))
+			m.int64(valueTypeUnit, intern(valueType[1]))
+		})
+	}
+
+	for _, key := range keys {
+		s := p.samples[key]
+		var ids []uint64
+		for _, loc := range s.stack {
+			id, ok := locationIDs[loc]
+			if !ok {
+				fid, ok := functionIDs[loc.function]
+				if !ok {
+					fid = uint64(len(functionIDs) + 1)
+					functionIDs[loc.function] = fid
+					f := loc.function
+					// pprof drops anything in angle brackets from names, as if it were a
+					// template argument
+					name := intern(strings.Trim(f.Name, "<>"))
+					file := intern(filename)
+					functions = append(functions, func(m *protobuf) {
+						m.uint64(functionID, fid)
+						m.int64(functionName, name)
+						m.int64(functionSystemName, name)
+						m.int64(functionFilename, file)
+						m.int64(functionStartLine, int64(f.Line))
+					})
+				}
+				id = uint64(len(locationIDs) + 1)
+				locationIDs[loc] = id
+				line := int64(loc.line)
+				locations = append(locations, func(m *protobuf) {
+					m.uint64(locationID, id)
+					m.message(locationLine, func(l *protobuf) {
+						l.uint64(lineFunctionID, fid)
+						l.int64(lineLine, line)
+					})
+				})
+			}
+			ids = append(ids, id)
+		}
+		profile.message(profileSample, func(m *protobuf) {
+			m.packed(sampleLocationID, ids)
+			m.packed(sampleValue, []uint64{uint64(s.statements), uint64(s.elapsed.Nanoseconds())})
+		})
+	}
+
+	for _, location := range locations {
+		profile.message(profileLocation, location)
+	}
+	for _, function := range functions {
+		profile.message(profileFunction, function)
+	}
+
+	periodType := [2]int64{intern("time"), intern("nanoseconds")}
+	for _, s := range table {
+		profile.string(profileStringTable, s)
+	}
+	profile.int64(profileTimeNanos, p.start.UnixNano())
+	profile.int64(profileDurationNanos, p.end.Sub(p.start).Nanoseconds())
+	profile.message(profilePeriodType, func(m *protobuf) {
+		m.int64(valueTypeType, periodType[0])
+		m.int64(valueTypeUnit, periodType[1])
+	})
+	profile.int64(profilePeriod, 1)
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(profile.data); err != nil {
+		return err
+	}
+	return gz.Close()
+}
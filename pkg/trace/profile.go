@@ -0,0 +1,271 @@
+package trace
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/reilandeubank/golox/pkg/interpreter"
+	"github.com/reilandeubank/golox/pkg/parser"
+)
+
+// scriptName names the top-level code in reports
+const scriptName = "<script>"
+
+// Function identifies a function by name and the line it is declared on
+type Function struct {
+	Name string
+	Line int // 0 for the top-level script
+}
+
+func (f Function) String() string {
+	if f.Line == 0 {
+		return f.Name
+	}
+	return fmt.Sprintf("%s (line %d)", f.Name, f.Line)
+}
+
+// Stat is what the profiler measured for a function or a line. Self time is spent
+// executing the function or line itself, while inclusive time adds the calls it made
+type Stat struct {
+	Count     int // calls of a function, or statements executed on a line
+	Self      time.Duration
+	Inclusive time.Duration
+}
+
+// location is a line within a function, the unit a pprof profile's stacks are made of
+type location struct {
+	function Function
+	line     int
+}
+
+type activation struct {
+	function Function
+	line     int
+	start    time.Time
+}
+
+// sample accumulates the self time spent with a particular call stack
+type sample struct {
+	stack      []location // innermost first
+	statements int64
+	elapsed    time.Duration
+}
+
+// Profiler is an interpreter.Hook that measures where a program spends its time. Time is
+// measured between hook calls, so the time natives take is charged to the line calling them
+type Profiler struct {
+	start   time.Time
+	end     time.Time
+	last    time.Time
+	stack   []activation
+	samples map[string]*sample
+
+	functions map[Function]*Stat
+	lines     map[int]*Stat
+
+	// how many activations of each function, and how many calls made from each line,
+	// are in progress; inclusive time is only added by the outermost
+	activeFunctions map[Function]int
+	activeLines     map[int]int
+}
+
+func NewProfiler() *Profiler {
+	return &Profiler{
+		samples:         make(map[string]*sample),
+		functions:       make(map[Function]*Stat),
+		lines:           make(map[int]*Stat),
+		activeFunctions: make(map[Function]int),
+		activeLines:     make(map[int]int),
+	}
+}
+
+func (p *Profiler) function(f Function) *Stat {
+	stat, ok := p.functions[f]
+	if !ok {
+		stat = &Stat{}
+		p.functions[f] = stat
+	}
+	return stat
+}
+
+func (p *Profiler) line(line int) *Stat {
+	stat, ok := p.lines[line]
+	if !ok {
+		stat = &Stat{}
+		p.lines[line] = stat
+	}
+	return stat
+}
+
+// charge adds the time since the last hook call to the innermost activation
+func (p *Profiler) charge(now time.Time) {
+	elapsed := now.Sub(p.last)
+	p.last = now
+	if len(p.stack) == 0 {
+		return
+	}
+	top := p.stack[len(p.stack)-1]
+
+	p.function(top.function).Self += elapsed
+	if top.line != 0 {
+		line := p.line(top.line)
+		line.Self += elapsed
+		if p.activeLines[top.line] == 0 {
+			line.Inclusive += elapsed
+		}
+	}
+	p.sample().elapsed += elapsed
+}
+
+// sample returns the sample for the current call stack
+func (p *Profiler) sample() *sample {
+	var key strings.Builder
+	for j := len(p.stack) - 1; j >= 0; j-- {
+		fmt.Fprintf(&key, "%s:%d:%d;", p.stack[j].function.Name, p.stack[j].function.Line, p.stack[j].line)
+	}
+	s, ok := p.samples[key.String()]
+	if !ok {
+		s = &sample{}
+		for j := len(p.stack) - 1; j >= 0; j-- {
+			s.stack = append(s.stack, location{p.stack[j].function, p.stack[j].line})
+		}
+		p.samples[key.String()] = s
+	}
+	return s
+}
+
+func (p *Profiler) BeforeStmt(i *interpreter.Interpreter, stmt parser.Stmt) error {
+	now := time.Now()
+	if len(p.stack) == 0 {
+		// The first statement of the script
+		p.start, p.last = now, now
+		script := Function{Name: scriptName}
+		p.function(script).Count++
+		p.activeFunctions[script]++
+		p.stack = append(p.stack, activation{function: script, start: now})
+	}
+	if _, ok := stmt.(parser.BlockStmt); ok {
+		return nil
+	}
+
+	// Time up to now belongs to the statement that was running
+	p.charge(now)
+	line := parser.StmtLine(stmt)
+	if line == 0 {
+		return nil
+	}
+	p.stack[len(p.stack)-1].line = line
+	p.line(line).Count++
+	p.sample().statements++
+	return nil
+}
+
+func (p *Profiler) EnterCall(i *interpreter.Interpreter, function interpreter.LoxFunction) {
+	now := time.Now()
+	p.charge(now)
+	if len(p.stack) > 0 {
+		p.activeLines[p.stack[len(p.stack)-1].line]++
+	}
+
+	f := Function{Name: function.Declaration.Name.Lexeme, Line: function.Declaration.Name.Line}
+	p.function(f).Count++
+	p.activeFunctions[f]++
+	// Until its first statement runs, a call's time goes to the line declaring it
+	p.stack = append(p.stack, activation{function: f, line: f.Line, start: now})
+}
+
+func (p *Profiler) ExitCall(i *interpreter.Interpreter, function interpreter.LoxFunction) {
+	now := time.Now()
+	p.charge(now)
+	if len(p.stack) < 2 {
+		return
+	}
+	callee := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	caller := p.stack[len(p.stack)-1]
+
+	elapsed := now.Sub(callee.start)
+	p.activeFunctions[callee.function]--
+	if p.activeFunctions[callee.function] == 0 {
+		p.function(callee.function).Inclusive += elapsed
+	}
+	p.activeLines[caller.line]--
+	if p.activeLines[caller.line] == 0 && caller.line != 0 {
+		p.line(caller.line).Inclusive += elapsed
+	}
+}
+
+// Stop ends the profile once the program has finished
+func (p *Profiler) Stop() {
+	if len(p.stack) == 0 {
+		return
+	}
+	p.end = time.Now()
+	p.charge(p.end)
+	script := Function{Name: scriptName}
+	p.function(script).Inclusive = p.end.Sub(p.start)
+	p.stack = nil
+}
+
+// Functions returns the statistics for each function that was called
+func (p *Profiler) Functions() map[Function]Stat {
+	functions := make(map[Function]Stat, len(p.functions))
+	for f, stat := range p.functions {
+		functions[f] = *stat
+	}
+	return functions
+}
+
+// Lines returns the statistics for each line that executed a statement
+func (p *Profiler) Lines() map[int]Stat {
+	lines := make(map[int]Stat, len(p.lines))
+	for line, stat := range p.lines {
+		lines[line] = *stat
+	}
+	return lines
+}
+
+// WriteReport writes tables of the functions and lines, most self time first
+func (p *Profiler) WriteReport(w io.Writer) error {
+	functions := make([]Function, 0, len(p.functions))
+	for f := range p.functions {
+		functions = append(functions, f)
+	}
+	sort.Slice(functions, func(a, b int) bool {
+		statA, statB := p.functions[functions[a]], p.functions[functions[b]]
+		if statA.Self != statB.Self {
+			return statA.Self > statB.Self
+		}
+		return functions[a].Line < functions[b].Line
+	})
+
+	lines := make([]int, 0, len(p.lines))
+	for line := range p.lines {
+		lines = append(lines, line)
+	}
+	sort.Slice(lines, func(a, b int) bool {
+		statA, statB := p.lines[lines[a]], p.lines[lines[b]]
+		if statA.Self != statB.Self {
+			return statA.Self > statB.Self
+		}
+		return lines[a] < lines[b]
+	})
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "CALLS\tSELF\tINCLUSIVE\t\tFUNCTION")
+	for _, f := range functions {
+		stat := p.functions[f]
+		fmt.Fprintf(table, "%d\t%v\t%v\t\t%s\n", stat.Count, stat.Self, stat.Inclusive, f)
+	}
+	fmt.Fprintln(table, "\t\t\t\t")
+	fmt.Fprintln(table, "COUNT\tSELF\tINCLUSIVE\t\tLINE")
+	for _, line := range lines {
+		stat := p.lines[line]
+		fmt.Fprintf(table, "%d\t%v\t%v\t\t%d\n", stat.Count, stat.Self, stat.Inclusive, line)
+	}
+	return table.Flush()
+}
@@ -0,0 +1,43 @@
+// Package trace provides interpreter hooks that report what a program does as it runs:
+// a statement-by-statement trace and a profiler.
+package trace
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/reilandeubank/golox/pkg/interpreter"
+	"github.com/reilandeubank/golox/pkg/parser"
+)
+
+// Tracer is an interpreter.Hook that writes a line for every statement executed, giving
+// its source line, the environment depth and the source text
+type Tracer struct {
+	w     io.Writer
+	lines []string
+}
+
+// NewTracer returns a tracer writing to w. source is the program being traced, used to
+// show the text of each statement's line
+func NewTracer(w io.Writer, source string) *Tracer {
+	return &Tracer{w: w, lines: strings.Split(source, "\n")}
+}
+
+func (t *Tracer) BeforeStmt(i *interpreter.Interpreter, stmt parser.Stmt) error {
+	// A block's own entry adds nothing to the statements it contains
+	if _, ok := stmt.(parser.BlockStmt); ok {
+		return nil
+	}
+	line := parser.StmtLine(stmt)
+	text := ""
+	if line >= 1 && line <= len(t.lines) {
+		text = strings.TrimSpace(t.lines[line-1])
+	}
+	fmt.Fprintf(t.w, "[line %d] depth %d: %s\n", line, i.ScopeDepth(), text)
+	return nil
+}
+
+func (t *Tracer) EnterCall(i *interpreter.Interpreter, function interpreter.LoxFunction) {}
+
+func (t *Tracer) ExitCall(i *interpreter.Interpreter, function interpreter.LoxFunction) {}
@@ -0,0 +1,144 @@
+package trace
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/reilandeubank/golox/pkg/interpreter"
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+const fib = `fun fib(n) {
+    if (n < 2) return n;
+    return fib(n - 1) + fib(n - 2);
+}
+print fib(5);
+`
+
+// run runs source with hook, discarding what it prints
+func run(t *testing.T, source string, hook interpreter.Hook) {
+	t.Helper()
+	thisScanner := scanner.NewScanner(source)
+	thisParser := parser.NewParser(thisScanner.ScanTokens())
+	statements, err := thisParser.Parse()
+	if err != nil || len(thisScanner.Errors) > 0 || len(thisParser.Errors) > 0 {
+		t.Fatalf("%q doesn't parse", source)
+	}
+	i := interpreter.NewInterpreter(interpreter.WithHook(hook), interpreter.WithStdout(io.Discard))
+	if err := i.Interpret(statements); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTracer(t *testing.T) {
+	var out bytes.Buffer
+	source := "var a = 1;\n{\n    var b = 2;\n}\nprint a;\n"
+	run(t, source, NewTracer(&out, source))
+	want := "[line 1] depth 0: var a = 1;\n[line 3] depth 1: var b = 2;\n[line 5] depth 0: print a;\n"
+	if out.String() != want {
+		t.Errorf("got trace:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestProfileCounts(t *testing.T) {
+	p := NewProfiler()
+	run(t, fib, p)
+	p.Stop()
+
+	functions := p.Functions()
+	if len(functions) != 2 {
+		t.Errorf("got %d functions, want the script and fib: %v", len(functions), functions)
+	}
+	if count := functions[Function{Name: scriptName}].Count; count != 1 {
+		t.Errorf("script ran %d times, want 1", count)
+	}
+	if count := functions[Function{Name: "fib", Line: 1}].Count; count != 15 {
+		t.Errorf("fib was called %d times, want 15", count)
+	}
+
+	lines := p.Lines()
+	// Line 2 runs its if in every call and its return in the 8 that reach n < 2
+	want := map[int]int{1: 1, 2: 15 + 8, 3: 7, 5: 1}
+	if len(lines) != len(want) {
+		t.Errorf("got lines %v, want %v", lines, want)
+	}
+	for line, count := range want {
+		if lines[line].Count != count {
+			t.Errorf("line %d ran %d statements, want %d", line, lines[line].Count, count)
+		}
+	}
+}
+
+func TestProfileTimes(t *testing.T) {
+	p := NewProfiler()
+	run(t, fib, p)
+	p.Stop()
+
+	// Every moment is charged to exactly one function, so the self times add up to the
+	// whole run, and recursion mustn't count a call's time more than once
+	functions := p.Functions()
+	script := functions[Function{Name: scriptName}]
+	var self int64
+	for f, stat := range functions {
+		self += int64(stat.Self)
+		if stat.Inclusive < stat.Self || stat.Inclusive > script.Inclusive {
+			t.Errorf("%s has self time %v and inclusive time %v, in a run of %v", f, stat.Self, stat.Inclusive, script.Inclusive)
+		}
+	}
+	if self != int64(script.Inclusive) {
+		t.Errorf("self times add up to %d ns, want the whole run's %d", self, script.Inclusive)
+	}
+	for line, stat := range p.Lines() {
+		if stat.Inclusive < stat.Self || stat.Inclusive > script.Inclusive {
+			t.Errorf("line %d has self time %v and inclusive time %v, in a run of %v", line, stat.Self, stat.Inclusive, script.Inclusive)
+		}
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	p := NewProfiler()
+	run(t, fib, p)
+	p.Stop()
+
+	var out bytes.Buffer
+	if err := p.WriteReport(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"CALLS", "fib (line 1)", "<script>", "LINE"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report doesn't mention %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestWriteProfile(t *testing.T) {
+	p := NewProfiler()
+	run(t, fib, p)
+	p.Stop()
+
+	var out bytes.Buffer
+	if err := p.WriteProfile(&out, "fib.lox"); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The names pprof shows are in the string table; pprof itself drops the brackets
+	for _, want := range []string{"statements", "nanoseconds", "fib", "fib.lox", "script"} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("profile has no string %q", want)
+		}
+	}
+	if bytes.Contains(data, []byte("<script>")) {
+		t.Error("profile names the script <script>, which pprof would drop")
+	}
+}
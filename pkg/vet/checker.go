@@ -0,0 +1,250 @@
+package vet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/reilandeubank/golox/pkg/interpreter"
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/resolve"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+// call is a call site whose callee resolved to a binding, checked once every
+// reassignment has been seen
+type call struct {
+	expr    parser.Call
+	binding *resolve.Binding
+}
+
+// checker walks the tree for the rules that look at its shape, taking what each name
+// refers to from the resolution
+type checker struct {
+	resolution *resolve.Resolution
+	calls      []call
+	findings   []Finding
+}
+
+func newChecker(statements []parser.Stmt) *checker {
+	return &checker{resolution: resolve.Resolve(statements)}
+}
+
+func (c *checker) report(rule string, severity Severity, token scanner.Token, format string, args ...interface{}) {
+	c.findings = append(c.findings, Finding{Rule: rule, Severity: severity, Line: token.Line, Column: token.Column, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) reportAt(rule string, severity Severity, line int, format string, args ...interface{}) {
+	c.findings = append(c.findings, Finding{Rule: rule, Severity: severity, Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) checkProgram(statements []parser.Stmt) {
+	c.checkBindings()
+	c.statements(statements)
+	c.checkCalls()
+}
+
+// checkBindings reports local bindings that are never used or that hide another
+func (c *checker) checkBindings() {
+	for _, b := range c.resolution.Bindings {
+		if b.Global {
+			continue
+		}
+		if b.Shadows != nil {
+			c.report(RuleShadow, Info, b.Name, "declaration of '%s' shadows %s", b.Name.Lexeme, describe(b.Shadows))
+		}
+		if b.Used || strings.HasPrefix(b.Name.Lexeme, "_") {
+			continue
+		}
+		switch b.Kind {
+		case resolve.Parameter:
+			c.report(RuleUnusedParameter, Info, b.Name, "parameter '%s' is never used", b.Name.Lexeme)
+		case resolve.Function:
+			c.report(RuleUnusedVariable, Warning, b.Name, "function '%s' is declared but never used", b.Name.Lexeme)
+		default:
+			c.report(RuleUnusedVariable, Warning, b.Name, "variable '%s' is declared but never used", b.Name.Lexeme)
+		}
+	}
+	for _, name := range c.resolution.Undeclared {
+		c.report(RuleUndeclaredAssignment, Error, name, "assignment to undeclared variable '%s'", name.Lexeme)
+	}
+}
+
+func describe(b *resolve.Binding) string {
+	switch b.Kind {
+	case resolve.Native:
+		if b.Native == nil {
+			return "the built-in constant"
+		}
+		return "the native function"
+	case resolve.Parameter:
+		return fmt.Sprintf("the parameter declared at line %d", b.Name.Line)
+	}
+	return fmt.Sprintf("the declaration at line %d", b.Name.Line)
+}
+
+func (c *checker) statements(statements []parser.Stmt) {
+	for j, stmt := range statements {
+		c.stmt(stmt)
+		if _, ok := stmt.(parser.ReturnStmt); ok && j+1 < len(statements) {
+			c.reportAt(RuleUnreachable, Warning, parser.StmtLine(statements[j+1]), "unreachable code after return")
+		}
+	}
+}
+
+func (c *checker) stmt(stmt parser.Stmt) {
+	if stmt != nil {
+		stmt.Accept(c)
+	}
+}
+
+func (c *checker) expr(expr parser.Expression) {
+	if expr != nil {
+		expr.Accept(c)
+	}
+}
+
+// condition checks an if or while condition for a constant literal
+func (c *checker) condition(expr parser.Expression, keyword string) {
+	inner := expr
+	for {
+		grouping, ok := inner.(parser.Grouping)
+		if !ok {
+			break
+		}
+		inner = grouping.Expression
+	}
+	// Literals with no line are the 'true' the parser supplies for 'for (;;)'
+	if literal, ok := inner.(parser.Literal); ok && literal.Line != 0 {
+		c.reportAt(RuleConstantCondition, Warning, literal.Line, "%s condition is always %s", keyword, truthiness(literal.Value))
+	}
+	c.expr(expr)
+}
+
+func truthiness(value interface{}) string {
+	if value == nil || value == false || value == 0.0 {
+		return "false"
+	}
+	return "true"
+}
+
+func (c *checker) checkCalls() {
+	for _, site := range c.calls {
+		b := site.binding
+		if b.Reassigned {
+			continue
+		}
+		got := len(site.expr.Arguments)
+		var callee interpreter.LoxCallable
+		switch {
+		case b.Kind == resolve.Function && b.Function != nil:
+			callee = interpreter.LoxFunction{Declaration: *b.Function}
+		case b.Kind == resolve.Native && b.Native != nil:
+			callee = b.Native
+		default:
+			continue
+		}
+		if !interpreter.Accepts(callee, got) {
+			c.report(RuleArgumentCount, Error, site.expr.Paren, "'%s' expects %s arguments but is called with %d", b.Name.Lexeme, interpreter.ArityString(callee), got)
+		}
+	}
+}
+
+func (c *checker) VisitExprStmt(e parser.ExprStmt) (interface{}, error) {
+	c.expr(e.Expression)
+	return nil, nil
+}
+
+func (c *checker) VisitPrintStmt(p parser.PrintStmt) (interface{}, error) {
+	c.expr(p.Expression)
+	return nil, nil
+}
+
+func (c *checker) VisitVarStmt(v parser.VarStmt) (interface{}, error) {
+	c.expr(v.Initializer)
+	return nil, nil
+}
+
+func (c *checker) VisitBlockStmt(b parser.BlockStmt) (interface{}, error) {
+	c.statements(b.Statements)
+	return nil, nil
+}
+
+func (c *checker) VisitIfStmt(i parser.IfStmt) (interface{}, error) {
+	c.condition(i.Condition, "if")
+	c.stmt(i.ThenBranch)
+	c.stmt(i.ElseBranch)
+	return nil, nil
+}
+
+func (c *checker) VisitWhileStmt(w parser.WhileStmt) (interface{}, error) {
+	c.condition(w.Condition, "while")
+	c.stmt(w.Body)
+	return nil, nil
+}
+
+func (c *checker) VisitFunctionStmt(f parser.FunctionStmt) (interface{}, error) {
+	c.statements(f.Body)
+	return nil, nil
+}
+
+func (c *checker) VisitReturnStmt(r parser.ReturnStmt) (interface{}, error) {
+	c.expr(r.Value)
+	return nil, nil
+}
+
+func (c *checker) VisitBinaryExpr(b parser.Binary) (interface{}, error) {
+	c.expr(b.Left)
+	c.expr(b.Right)
+	return nil, nil
+}
+
+func (c *checker) VisitGroupingExpr(g parser.Grouping) (interface{}, error) {
+	c.expr(g.Expression)
+	return nil, nil
+}
+
+func (c *checker) VisitLiteralExpr(l parser.Literal) (interface{}, error) {
+	return nil, nil
+}
+
+func (c *checker) VisitUnaryExpr(u parser.Unary) (interface{}, error) {
+	c.expr(u.Right)
+	return nil, nil
+}
+
+func (c *checker) VisitVariableExpr(v parser.Variable) (interface{}, error) {
+	return nil, nil
+}
+
+func (c *checker) VisitAssignExpr(a parser.Assign) (interface{}, error) {
+	if variable, ok := a.Value.(parser.Variable); ok && variable.Name.Lexeme == a.Name.Lexeme {
+		c.report(RuleSelfAssignment, Warning, a.Name, "self-assignment of '%s'", a.Name.Lexeme)
+	}
+	c.expr(a.Value)
+	return nil, nil
+}
+
+func (c *checker) VisitLogicalExpr(l parser.Logical) (interface{}, error) {
+	c.expr(l.Left)
+	c.expr(l.Right)
+	return nil, nil
+}
+
+func (c *checker) VisitCallExpr(expr parser.Call) (interface{}, error) {
+	c.expr(expr.Callee)
+	for _, argument := range expr.Arguments {
+		c.expr(argument)
+	}
+	if variable, ok := expr.Callee.(parser.Variable); ok {
+		if b, ok := c.resolution.References[resolve.KeyOf(variable.Name)]; ok {
+			c.calls = append(c.calls, call{expr: expr, binding: b})
+		}
+	}
+	return nil, nil
+}
+
+func (c *checker) VisitIndexExpr(i parser.Index) (interface{}, error) {
+	c.expr(i.Object)
+	c.expr(i.Index)
+	return nil, nil
+}
@@ -0,0 +1,119 @@
+// Package vet reports suspicious constructs in Lox programs that parse and may well run,
+// but are probably mistakes.
+package vet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/reilandeubank/golox/pkg/parser"
+	"github.com/reilandeubank/golox/pkg/scanner"
+)
+
+// Rule IDs, as printed with each finding and accepted by // vet:ignore
+const (
+	RuleUnusedVariable       = "unused-variable"
+	RuleUnusedParameter      = "unused-parameter"
+	RuleUnreachable          = "unreachable"
+	RuleUndeclaredAssignment = "undeclared-assignment"
+	RuleShadow               = "shadow"
+	RuleSelfAssignment       = "self-assignment"
+	RuleConstantCondition    = "constant-condition"
+	RuleArgumentCount        = "argument-count"
+)
+
+type Severity int
+
+const (
+	Info Severity = iota
+	Warning
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	}
+	return "error"
+}
+
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Line     int
+	Column   int // 0 when only the line is known
+	Message  string
+}
+
+func (f Finding) String() string {
+	position := fmt.Sprint(f.Line)
+	if f.Column != 0 {
+		position += fmt.Sprintf(":%d", f.Column)
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", position, f.Severity, f.Message, f.Rule)
+}
+
+// ErrSyntax is returned for source that doesn't parse. The errors themselves are reported
+// to os.Stderr like any other parse error
+var ErrSyntax = errors.New("source has syntax errors")
+
+// Check runs every rule over source, returning the findings ordered by position. A finding
+// is dropped if its line has a "// vet:ignore" comment, optionally followed by a
+// comma-separated list of the rule IDs to ignore
+func Check(source string) ([]Finding, error) {
+	thisScanner := scanner.NewScanner(source)
+	thisParser := parser.NewParser(thisScanner.ScanTokens())
+	statements, err := thisParser.Parse()
+	if err != nil || len(thisScanner.Errors) > 0 || len(thisParser.Errors) > 0 {
+		return nil, ErrSyntax
+	}
+
+	c := newChecker(statements)
+	c.checkProgram(statements)
+
+	ignored := ignoredRules(source)
+	var findings []Finding
+	for _, finding := range c.findings {
+		rules, ok := ignored[finding.Line]
+		if ok && (len(rules) == 0 || rules[finding.Rule]) {
+			continue
+		}
+		findings = append(findings, finding)
+	}
+	sort.SliceStable(findings, func(a, b int) bool {
+		if findings[a].Line != findings[b].Line {
+			return findings[a].Line < findings[b].Line
+		}
+		return findings[a].Column < findings[b].Column
+	})
+	return findings, nil
+}
+
+// ignoredRules maps each line with a vet:ignore comment to the rules it names, which is
+// empty when it ignores everything
+func ignoredRules(source string) map[int]map[string]bool {
+	ignored := make(map[int]map[string]bool)
+	commented := scanner.NewScannerWithComments(source)
+	for _, token := range commented.ScanTokens() {
+		if token.Type != scanner.COMMENT {
+			continue
+		}
+		_, directive, ok := strings.Cut(token.Lexeme, "vet:ignore")
+		if !ok {
+			continue
+		}
+		rules := make(map[string]bool)
+		for _, rule := range strings.Split(directive, ",") {
+			if rule = strings.TrimSpace(rule); rule != "" {
+				rules[rule] = true
+			}
+		}
+		ignored[token.Line] = rules
+	}
+	return ignored
+}
@@ -0,0 +1,75 @@
+package vet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Each file in testdata exercises one rule, marking the findings expected on a line with
+// a comment such as:
+//
+//	a = a; // want: warning: self-assignment of 'a' (self-assignment)
+func TestRules(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.lox"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		path := path
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".lox"), func(t *testing.T) {
+			source, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			findings, err := Check(string(source))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, finding := range findings {
+				got = append(got, fmt.Sprintf("%d: %s: %s (%s)", finding.Line, finding.Severity, finding.Message, finding.Rule))
+			}
+			want := wanted(string(source))
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("got findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+// wanted returns the findings the want comments in source expect, prefixed by line, in
+// the order Check returns them
+func wanted(source string) []string {
+	var want []string
+	for n, line := range strings.Split(source, "\n") {
+		parts := strings.Split(line, "// want: ")
+		for _, expected := range parts[1:] {
+			want = append(want, fmt.Sprintf("%d: %s", n+1, strings.TrimSpace(expected)))
+		}
+	}
+	return want
+}
+
+func TestIgnoredRules(t *testing.T) {
+	ignored := ignoredRules("var a;\na = a; // vet:ignore\nb = b; // vet:ignore shadow, self-assignment\n")
+	if rules, ok := ignored[2]; !ok || len(rules) != 0 {
+		t.Errorf("line 2: got %v, want every rule ignored", rules)
+	}
+	if rules := ignored[3]; len(rules) != 2 || !rules[RuleShadow] || !rules[RuleSelfAssignment] {
+		t.Errorf("line 3: got %v, want shadow and self-assignment", rules)
+	}
+	if _, ok := ignored[1]; ok {
+		t.Error("line 1 has no directive but is ignored")
+	}
+}
+
+func TestCheckSyntaxError(t *testing.T) {
+	_, err := Check("var = 1;")
+	if err != ErrSyntax {
+		t.Errorf("got %v, want ErrSyntax", err)
+	}
+}